@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// Runner is a tflint.Runner that remembers the content it fetched and the values computed from it during a run.
+// Every rule of the ruleset checks the module with the same runner, so they share the provider configurations and
+// tags instead of each asking TFLint for them and evaluating them again.
+type Runner struct {
+	// The wrapped runner answers everything that isn't cached
+	tflint.Runner
+
+	store *Store
+}
+
+var _ tflint.Runner = &Runner{}
+
+// NewRunner returns a runner caching the content and values of the runner
+func NewRunner(runner tflint.Runner) *Runner {
+	return &Runner{Runner: runner, store: NewStore()}
+}
+
+// Cache returns the store of the runner
+func (r *Runner) Cache() *Store {
+	return r.store
+}
+
+// GetModuleContent gets a content of the module, fetching it only once for the same schema and options
+func (r *Runner) GetModuleContent(schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (*hclext.BodyContent, error) {
+	key, err := contentKey("module", "", schema, opts)
+	if err != nil {
+		return nil, err
+	}
+	return Get(r, key, func() (*hclext.BodyContent, error) {
+		return r.Runner.GetModuleContent(schema, opts)
+	})
+}
+
+// GetResourceContent gets a resource content of the module, fetching it only once for the same schema and options
+func (r *Runner) GetResourceContent(name string, schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (*hclext.BodyContent, error) {
+	key, err := contentKey("resource", name, schema, opts)
+	if err != nil {
+		return nil, err
+	}
+	return Get(r, key, func() (*hclext.BodyContent, error) {
+		return r.Runner.GetResourceContent(name, schema, opts)
+	})
+}
+
+// GetProviderContent gets a provider content of the module, fetching it only once for the same schema and options
+func (r *Runner) GetProviderContent(name string, schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (*hclext.BodyContent, error) {
+	key, err := contentKey("provider", name, schema, opts)
+	if err != nil {
+		return nil, err
+	}
+	return Get(r, key, func() (*hclext.BodyContent, error) {
+		return r.Runner.GetProviderContent(name, schema, opts)
+	})
+}
+
+// Returns the key content fetched with the schema and options is cached under
+func contentKey(kind string, name string, schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (string, error) {
+	schemaKey, err := json.Marshal(schema)
+	if err != nil {
+		return "", err
+	}
+	optsKey, err := json.Marshal(opts)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("cache.%s.%s:%s:%s", kind, name, schemaKey, optsKey), nil
+}
+
+// Store holds the values computed for one module during a run, by key
+type Store struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+// NewStore returns an empty store
+func NewStore() *Store {
+	return &Store{entries: map[string]*entry{}}
+}
+
+// Holder is implemented by the runners that have a store, such as Runner
+type Holder interface {
+	Cache() *Store
+}
+
+// Get returns the value cached under the key in the store of the runner, computing it the first time it is asked for.
+// Errors are cached as well. Runners without a store compute the value every time.
+// Cached values are shared by every rule, so they must not be modified.
+func Get[T any](runner tflint.Runner, key string, compute func() (T, error)) (T, error) {
+	holder, ok := runner.(Holder)
+	if !ok {
+		return compute()
+	}
+
+	store := holder.Cache()
+	store.mu.Lock()
+	cached, exists := store.entries[key]
+	if !exists {
+		cached = &entry{}
+		store.entries[key] = cached
+	}
+	store.mu.Unlock()
+
+	cached.once.Do(func() {
+		cached.value, cached.err = compute()
+	})
+	// A nil value is stored as a nil interface, which is returned as the zero value of T
+	value, _ := cached.value.(T)
+	return value, cached.err
+}
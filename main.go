@@ -9,12 +9,20 @@ import (
 
 func main() {
 	plugin.Serve(&plugin.ServeOpts{
-		RuleSet: &tflint.BuiltinRuleSet{
-			Name:    "tflint-ruleset-0north-plugin",
-			Version: project.Version,
-			Rules: []tflint.Rule{
-				rules.NewEnsureDefaultTagsRule(),
-				rules.NewValidateTagsRule(),
+		RuleSet: &rules.RuleSet{
+			BuiltinRuleSet: tflint.BuiltinRuleSet{
+				Name:    "tflint-ruleset-0north-plugin",
+				Version: project.Version,
+				Rules: []tflint.Rule{
+					rules.NewEnsureDefaultTagsRule(),
+					rules.NewValidateTagsRule(),
+					rules.NewTagCountLimitRule(),
+					rules.NewDefaultTagsDuplicatesRule(),
+					rules.NewTagKeyCaseCollisionRule(),
+					rules.NewApplyTimeTagValuesRule(),
+					rules.NewModuleTagsInputRule(),
+					rules.NewTagCoverageRule(),
+				},
 			},
 		},
 	})
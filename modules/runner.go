@@ -0,0 +1,144 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/terraform-linters/tflint-plugin-sdk/terraform/addrs"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Runner is a tflint.Runner for a module called from the root module.
+// TFLint inspects called modules itself when run with --call-module-type=all (--module before v0.50), checking the ruleset
+// once for every module, but only reports issues of a called module on expressions derived from its variables, at the
+// argument of the module call. Issues elsewhere in the module would be dropped, so they are held back until the root
+// module is checked and reported on the module call of the root module, prefixed with the address inside the module.
+type Runner struct {
+	// The runner of TFLint answers everything, the module it inspects is the called module
+	tflint.Runner
+
+	path   addrs.Module
+	files  map[string]*hcl.File
+	issues *Issues
+}
+
+// Issues holds the issues of called modules until the root module is checked
+type Issues struct {
+	issues []*issue
+}
+
+type issue struct {
+	rule    tflint.Rule
+	message string
+	// The name of the module call in the root module the issue is reported on
+	call string
+}
+
+var _ tflint.Runner = &Runner{}
+
+// NewRunner returns a runner for the module inspected by the runner of TFLint, which is that runner itself for the root module.
+// The issues of a called module are added to issues.
+func NewRunner(runner tflint.Runner, issues *Issues) (tflint.Runner, error) {
+	path, err := runner.GetModulePath()
+	if err != nil {
+		return nil, err
+	}
+	if path.IsRoot() {
+		return runner, nil
+	}
+
+	files, err := runner.GetFiles()
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{Runner: runner, path: path, files: files, issues: issues}, nil
+}
+
+// EmitIssue holds the issue back for the root module, prefixed with the address it is about, e.g. module.network.aws_vpc.main: ...
+func (r *Runner) EmitIssue(rule tflint.Rule, message string, issueRange hcl.Range) error {
+	// Issues outside the called module, such as on the provider configurations of the root module, are reported when checking the module they are in
+	if _, exists := r.files[issueRange.Filename]; !exists {
+		return nil
+	}
+
+	// Issues about an instance of a resource using count or for_each already name it, e.g. aws_s3_bucket.b["logs"]: ...
+	address := AddressAt(r.files, issueRange)
+	if address != "" && strings.HasPrefix(message, address+"[") {
+		if instance, rest, found := strings.Cut(message, "]: "); found {
+			address, message = instance+"]", rest
+		}
+	}
+	address = strings.TrimSuffix(fmt.Sprintf("%s.%s", r.path, address), ".")
+	r.issues.issues = append(r.issues.issues, &issue{rule: rule, message: fmt.Sprintf("%s: %s", address, message), call: r.path[0]})
+	return nil
+}
+
+// Emit reports the issues of the called modules with the runner of the root module, on the module call they are in
+func (i *Issues) Emit(runner tflint.Runner) error {
+	if len(i.issues) == 0 {
+		return nil
+	}
+
+	content, err := runner.GetModuleContent(&hclext.BodySchema{
+		Blocks: []hclext.BlockSchema{{Type: "module", LabelNames: []string{"name"}}},
+	}, &tflint.GetModuleContentOption{ExpandMode: tflint.ExpandModeNone})
+	if err != nil {
+		return err
+	}
+	calls := map[string]hcl.Range{}
+	for _, block := range content.Blocks {
+		calls[block.Labels[0]] = block.DefRange
+	}
+
+	for _, issue := range i.issues {
+		issueRange, exists := calls[issue.call]
+		if !exists {
+			continue
+		}
+		if err := runner.EmitIssue(issue.rule, issue.message, issueRange); err != nil {
+			return err
+		}
+	}
+	i.issues = nil
+	return nil
+}
+
+// AddressAt returns the address of the resource, data source, module call or provider configuration the range is in,
+// e.g. aws_s3_bucket.b or provider.aws.west, or "" if it is in none of them
+func AddressAt(files map[string]*hcl.File, issueRange hcl.Range) string {
+	file, exists := files[issueRange.Filename]
+	if !exists {
+		return ""
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return ""
+	}
+
+	for _, block := range body.Blocks {
+		if !block.Range().ContainsPos(issueRange.Start) {
+			continue
+		}
+		switch {
+		case block.Type == "resource" && len(block.Labels) == 2:
+			return fmt.Sprintf("%s.%s", block.Labels[0], block.Labels[1])
+		case block.Type == "data" && len(block.Labels) == 2:
+			return fmt.Sprintf("data.%s.%s", block.Labels[0], block.Labels[1])
+		case block.Type == "module" && len(block.Labels) == 1:
+			return fmt.Sprintf("module.%s", block.Labels[0])
+		case block.Type == "provider" && len(block.Labels) == 1:
+			address := fmt.Sprintf("provider.%s", block.Labels[0])
+			if attribute, exists := block.Body.Attributes["alias"]; exists {
+				if alias, diags := attribute.Expr.Value(nil); !diags.HasErrors() && alias.Type() == cty.String && alias.IsKnown() && !alias.IsNull() {
+					address += "." + alias.AsString()
+				}
+			}
+			return address
+		}
+	}
+	return ""
+}
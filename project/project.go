@@ -7,5 +7,5 @@ const Version string = "1.0.0"
 
 // ReferenceLink returns the rule reference link
 func ReferenceLink(name string) string {
-	return fmt.Sprintf("https://github.com/0north/tflint-ruleset-0north-plugin/blob/v%s/docs/rules/%s.md", Version, name)
+	return fmt.Sprintf("https://github.com/0north/tflint-ruleset-0north-plugin/blob/v%s/docs/rules/%s_rule.md", Version, name)
 }
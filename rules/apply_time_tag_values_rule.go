@@ -0,0 +1,340 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/project"
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
+	"golang.org/x/exp/slices"
+)
+
+// Kinds of references a tag value can contain
+const (
+	referenceKindResource       = "resource"
+	referenceKindImpureFunction = "impure_function"
+	referenceKindData           = "data"
+	referenceKindModule         = "module"
+	referenceKindVariable       = "variable"
+	referenceKindLocal          = "local"
+)
+
+var referenceKinds = []string{
+	referenceKindResource,
+	referenceKindImpureFunction,
+	referenceKindData,
+	referenceKindModule,
+	referenceKindVariable,
+	referenceKindLocal,
+}
+
+// Functions returning a different value on every run
+// https://developer.hashicorp.com/terraform/language/functions
+var impureFunctions = []string{"bcrypt", "timestamp", "uuid"}
+
+// ApplyTimeTagValuesRule definition
+type ApplyTimeTagValuesRule struct {
+	tflint.DefaultRule
+	utils.Violations
+}
+
+// ApplyTimeTagValuesRuleConfig is a config of ApplyTimeTagValuesRule
+type ApplyTimeTagValuesRuleConfig struct {
+	Forbid  []string `hclext:"forbid,optional"`
+	Exclude []string `hclext:"exclude,optional"`
+}
+
+// tagValueReference is something a tag value depends on
+type tagValueReference struct {
+	Kind string
+	Name string
+	Via  []string
+}
+
+// NewApplyTimeTagValuesRule returns a new rule
+func NewApplyTimeTagValuesRule() *ApplyTimeTagValuesRule {
+	return &ApplyTimeTagValuesRule{}
+}
+
+// Returns a copy of the rule with the violation of the issue it emits attached
+func (r *ApplyTimeTagValuesRule) withViolation(kind string, tags ...string) tflint.Rule {
+	return &ApplyTimeTagValuesRule{Violations: utils.NewViolations(kind, tags...)}
+}
+
+// Name returns the rule name
+func (r *ApplyTimeTagValuesRule) Name() string {
+	return "apply_time_tag_values"
+}
+
+// Enabled returns whether the rule is enabled by default
+func (r *ApplyTimeTagValuesRule) Enabled() bool {
+	return false
+}
+
+// Severity returns the rule severity
+func (r *ApplyTimeTagValuesRule) Severity() tflint.Severity {
+	return tflint.ERROR
+}
+
+// Link returns the rule reference link
+func (r *ApplyTimeTagValuesRule) Link() string {
+	return project.ReferenceLink(r.Name())
+}
+
+// Checks the rule
+func (r *ApplyTimeTagValuesRule) Check(runner tflint.Runner) error {
+	config := &ApplyTimeTagValuesRuleConfig{}
+	if err := runner.DecodeRuleConfig(r.Name(), config); err != nil {
+		return err
+	}
+	if config.Forbid == nil {
+		config.Forbid = []string{referenceKindResource, referenceKindImpureFunction}
+	}
+	for _, kind := range config.Forbid {
+		if !slices.Contains(referenceKinds, kind) {
+			return fmt.Errorf("unknown reference kind \"%s\" in forbid, valid kinds are \"%s\"", kind, strings.Join(referenceKinds, "\", \""))
+		}
+	}
+
+	locals, err := getLocals(runner)
+	if err != nil {
+		return err
+	}
+
+	for _, provider := range tagging.Providers {
+		providerConfigs, err := tagging.GetProviderConfigs(runner, provider)
+		if err != nil {
+			return err
+		}
+
+		for _, providerConfig := range providerConfigs {
+			for _, tags := range providerConfig.DefaultTags {
+				err := r.verifyTags(runner, config, locals, "provider "+defaultTagsName(provider), tags)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		resources, err := tagging.GetResources(runner, provider, config.Exclude)
+		if err != nil {
+			return err
+		}
+
+		// The expressions are the same for every instance of a resource using count or for_each, so they are verified once
+		verified := map[hcl.Range]bool{}
+		for _, resource := range resources {
+			if verified[resource.Block.DefRange] {
+				continue
+			}
+			verified[resource.Block.DefRange] = true
+
+			for _, tags := range resource.Tags {
+				err := r.verifyTags(runner, config, locals, fmt.Sprintf("%s.%s", resource.Type, resource.Name), tags)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Verifies the tag values of tags written as an expression. Tags written as blocks are not checked.
+func (r *ApplyTimeTagValuesRule) verifyTags(runner tflint.Runner, config *ApplyTimeTagValuesRuleConfig, locals hclext.Attributes, owner string, tags *tagging.Tags) error {
+	if tags.Expr == nil {
+		return nil
+	}
+	return r.verifyTagValues(runner, config, locals, owner, writtenExpr(runner, tags.Expr))
+}
+
+// Returns the expression as written. TFLint binds the expressions referring to each or count to their value for every
+// instance of a resource using count or for_each, which hides what they refer to, so those are parsed again from the file.
+func writtenExpr(runner tflint.Runner, expr hcl.Expression) hcl.Expression {
+	if _, bound := expr.(*hclext.BoundExpr); !bound {
+		return expr
+	}
+	file, err := runner.GetFile(expr.Range().Filename)
+	if err != nil || file == nil {
+		return expr
+	}
+	written, diags := hclsyntax.ParseExpression(expr.Range().SliceBytes(file.Bytes), expr.Range().Filename, expr.Range().Start)
+	if diags.HasErrors() {
+		return expr
+	}
+	return written
+}
+
+// Returns the local values of the module by name
+func getLocals(runner tflint.Runner) (hclext.Attributes, error) {
+	content, err := runner.GetModuleContent(&hclext.BodySchema{
+		Blocks: []hclext.BlockSchema{
+			{
+				Type: "locals",
+				Body: &hclext.BodySchema{Mode: hclext.SchemaJustAttributesMode},
+			},
+		},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	locals := hclext.Attributes{}
+	for _, block := range content.Blocks {
+		for name, attribute := range block.Body.Attributes {
+			locals[name] = attribute
+		}
+	}
+	return locals, nil
+}
+
+// Goes through the tag values in the expression and reports the forbidden references they contain.
+// Tags written as an object (also inside merge) are reported per key, other expressions as a whole.
+func (r *ApplyTimeTagValuesRule) verifyTagValues(runner tflint.Runner, config *ApplyTimeTagValuesRuleConfig, locals hclext.Attributes, owner string, expr hcl.Expression) error {
+	switch expr := expr.(type) {
+	case *hclsyntax.ObjectConsExpr:
+		for _, item := range expr.Items {
+			key, diags := item.KeyExpr.Value(nil)
+			if diags.HasErrors() || !key.IsKnown() || key.IsNull() || key.Type() != cty.String {
+				err := r.emitReferences(runner, config, locals, fmt.Sprintf("The tags expression of %s", owner), "", item.KeyExpr)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			err := r.emitReferences(runner, config, locals, fmt.Sprintf("Tag \"%s\" on %s", key.AsString(), owner), key.AsString(), item.ValueExpr)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case *hclsyntax.FunctionCallExpr:
+		if expr.Name == "merge" {
+			for _, arg := range expr.Args {
+				err := r.verifyTagValues(runner, config, locals, owner, arg)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	return r.emitReferences(runner, config, locals, fmt.Sprintf("The tags expression of %s", owner), "", expr)
+}
+
+// Emits an issue for every forbidden reference in the expression. The tag is the key the expression is the value of, if any.
+func (r *ApplyTimeTagValuesRule) emitReferences(runner tflint.Runner, config *ApplyTimeTagValuesRuleConfig, locals hclext.Attributes, subject string, tag string, expr hcl.Expression) error {
+	for _, reference := range findReferences(expr, locals, []string{}) {
+		if !slices.Contains(config.Forbid, reference.Kind) {
+			continue
+		}
+
+		var message string
+		switch reference.Kind {
+		case referenceKindResource:
+			message = fmt.Sprintf("%s references %s, which is only known after apply", subject, reference.Name)
+		case referenceKindImpureFunction:
+			message = fmt.Sprintf("%s calls %s, which returns a different value on every run", subject, reference.Name)
+		case referenceKindData:
+			message = fmt.Sprintf("%s references the data source %s", subject, reference.Name)
+		case referenceKindModule:
+			message = fmt.Sprintf("%s references the module output %s", subject, reference.Name)
+		case referenceKindVariable:
+			message = fmt.Sprintf("%s references the variable %s", subject, reference.Name)
+		case referenceKindLocal:
+			message = fmt.Sprintf("%s references the local value %s", subject, reference.Name)
+		}
+		if len(reference.Via) > 0 {
+			message += fmt.Sprintf(" (via %s)", strings.Join(reference.Via, ", "))
+		}
+
+		rule := r.withViolation(reference.Kind + "_reference:" + reference.Name)
+		if tag != "" {
+			rule = r.withViolation(reference.Kind+"_reference:"+reference.Name, tag)
+		}
+		err := runner.EmitIssue(rule, message, expr.Range())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Classifies what the expression refers to, following local values to what they refer to in turn
+func findReferences(expr hcl.Expression, locals hclext.Attributes, via []string) []tagValueReference {
+	references := []tagValueReference{}
+
+	for _, traversal := range expr.Variables() {
+		name := traversalString(traversal)
+
+		switch traversal.RootName() {
+		case "var":
+			references = append(references, tagValueReference{Kind: referenceKindVariable, Name: name, Via: via})
+		case "data":
+			references = append(references, tagValueReference{Kind: referenceKindData, Name: name, Via: via})
+		case "module":
+			references = append(references, tagValueReference{Kind: referenceKindModule, Name: name, Via: via})
+		case "local":
+			references = append(references, tagValueReference{Kind: referenceKindLocal, Name: name, Via: via})
+
+			if len(traversal) < 2 {
+				continue
+			}
+			step, ok := traversal[1].(hcl.TraverseAttr)
+			if !ok {
+				continue
+			}
+			local := "local." + step.Name
+			if attribute, exists := locals[step.Name]; exists && !slices.Contains(via, local) {
+				references = append(references, findReferences(attribute.Expr, locals, append(slices.Clone(via), local))...)
+			}
+		case "each", "count", "self", "path", "terraform":
+			continue
+		default:
+			references = append(references, tagValueReference{Kind: referenceKindResource, Name: name, Via: via})
+		}
+	}
+
+	if node, ok := expr.(hclsyntax.Node); ok {
+		hclsyntax.VisitAll(node, func(node hclsyntax.Node) hcl.Diagnostics {
+			if call, ok := node.(*hclsyntax.FunctionCallExpr); ok && slices.Contains(impureFunctions, call.Name) {
+				references = append(references, tagValueReference{Kind: referenceKindImpureFunction, Name: call.Name + "()", Via: via})
+			}
+			return nil
+		})
+	}
+
+	return references
+}
+
+// Renders a traversal the way it is written, e.g. aws_iam_user.x.arn
+func traversalString(traversal hcl.Traversal) string {
+	var builder strings.Builder
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			builder.WriteString(step.Name)
+		case hcl.TraverseAttr:
+			builder.WriteString("." + step.Name)
+		case hcl.TraverseIndex:
+			if step.Key.Type() == cty.String {
+				builder.WriteString(fmt.Sprintf("[%q]", step.Key.AsString()))
+			} else if step.Key.Type() == cty.Number {
+				builder.WriteString(fmt.Sprintf("[%s]", step.Key.AsBigFloat().Text('f', -1)))
+			}
+		case hcl.TraverseSplat:
+			builder.WriteString("[*]")
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,198 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_ApplyTimeTagValuesRule(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Content  string
+		Config   string
+		Expected helper.Issues
+	}{
+		{
+			Name: "Succeeds_ForResource_WithStaticTags",
+			Content: `
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					team = "platform-engineering"
+					name = var.name
+				}
+			}`,
+			Config: `
+			rule "apply_time_tag_values" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForResource_WithResourceAttributeAndImpureFunction",
+			Content: `
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					owner = aws_iam_user.owner.arn
+					build = timestamp()
+				}
+			}`,
+			Config: `
+			rule "apply_time_tag_values" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewApplyTimeTagValuesRule(),
+					Message: "Tag \"owner\" on aws_instance.ec2_instance references aws_iam_user.owner.arn, which is only known after apply",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 4, Column: 14},
+						End:      hcl.Pos{Line: 4, Column: 36},
+					},
+				},
+				{
+					Rule:    NewApplyTimeTagValuesRule(),
+					Message: "Tag \"build\" on aws_instance.ec2_instance calls timestamp(), which returns a different value on every run",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 14},
+						End:      hcl.Pos{Line: 5, Column: 25},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForProvider_WithResourceAttributeViaLocal",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = merge(local.tags, {
+						team = "platform-engineering"
+					})
+				}
+			}
+
+			locals {
+				owner = aws_iam_user.owner.arn
+				tags  = { owner = local.owner }
+			}`,
+			Config: `
+			rule "apply_time_tag_values" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewApplyTimeTagValuesRule(),
+					Message: "The tags expression of provider default_tags references aws_iam_user.owner.arn, which is only known after apply (via local.tags, local.owner)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 19},
+						End:      hcl.Pos{Line: 5, Column: 29},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForResource_WithForbiddenDataSourceAndVariable",
+			Content: `
+			resource "aws_s3_bucket" "bucket" {
+				tags = {
+					account = data.aws_caller_identity.current.account_id
+					team    = var.team
+					name    = "logs"
+				}
+			}`,
+			Config: `
+			rule "apply_time_tag_values" {
+				enabled = true
+				forbid  = ["data", "variable"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewApplyTimeTagValuesRule(),
+					Message: "Tag \"account\" on aws_s3_bucket.bucket references the data source data.aws_caller_identity.current.account_id",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 4, Column: 16},
+						End:      hcl.Pos{Line: 4, Column: 59},
+					},
+				},
+				{
+					Rule:    NewApplyTimeTagValuesRule(),
+					Message: "Tag \"team\" on aws_s3_bucket.bucket references the variable var.team",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 16},
+						End:      hcl.Pos{Line: 5, Column: 24},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForForEachResource_WithForbiddenVariable_Once",
+			Content: `
+			variable "env" {
+				default = "prod"
+			}
+
+			resource "aws_s3_bucket" "b" {
+				for_each = {
+					data = "platform-engineering"
+					logs = "finance"
+				}
+				tags = {
+					env  = var.env
+					team = each.value
+				}
+			}`,
+			Config: `
+			rule "apply_time_tag_values" {
+				enabled = true
+				forbid  = ["variable"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewApplyTimeTagValuesRule(),
+					Message: "Tag \"env\" on aws_s3_bucket.b references the variable var.env",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 12, Column: 13},
+						End:      hcl.Pos{Line: 12, Column: 20},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForResource_WithResourceAttribute_ButExcluded",
+			Content: `
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					owner = aws_iam_user.owner.arn
+				}
+			}`,
+			Config: `
+			rule "apply_time_tag_values" {
+				enabled = true
+				exclude = ["aws_instance"]
+			}`,
+			Expected: helper.Issues{},
+		},
+	}
+
+	rule := NewApplyTimeTagValuesRule()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			runner := newTestRunner(t, map[string]string{"resource.tf": test.Content, ".tflint.hcl": test.Config})
+
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+
+			helper.AssertIssues(t, test.Expected, runner.Issues)
+		})
+	}
+}
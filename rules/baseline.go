@@ -0,0 +1,288 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/modules"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"golang.org/x/exp/slices"
+)
+
+// Setting the environment variable to a non-empty value writes the current violations to the baseline instead of reporting them
+const baselineUpdateEnv = "TFLINT_0NORTH_UPDATE_BASELINE"
+
+// BaselineConfig is the configuration of the baseline in the plugin block
+type BaselineConfig struct {
+	Path string `hclext:"path"`
+}
+
+// baselineFile is the content of the baseline file
+type baselineFile struct {
+	Violations []*fingerprint `json:"violations"`
+}
+
+// fingerprint identifies a violation across runs, regardless of where it is in the files and how its message is worded.
+// Issues without a violation are identified by their rule and address alone.
+type fingerprint struct {
+	Rule    string `json:"rule"`
+	Address string `json:"address"`
+	Kind    string `json:"kind"`
+	Tag     string `json:"tag,omitempty"`
+}
+
+// baseline holds the violations of the baseline file and those found during the run
+type baseline struct {
+	path   string
+	update bool
+	// Violations of the baseline file
+	known map[fingerprint]bool
+	// Violations found during the run
+	found map[fingerprint]bool
+	// Paths of the modules checked during the run, e.g. module.network or "" for the root module
+	checked map[string]bool
+}
+
+// baselineRunner reports only the issues with violations that aren't in the baseline
+type baselineRunner struct {
+	tflint.Runner
+	baseline *baseline
+}
+
+// Reads the baseline file. A missing file is an empty baseline, so the first run reports every violation.
+func loadBaseline(runner tflint.Runner, config *BaselineConfig) (*baseline, error) {
+	path := config.Path
+	if !filepath.IsAbs(path) {
+		wd, err := runner.GetOriginalwd()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(wd, path)
+	}
+
+	b := &baseline{
+		path:    path,
+		update:  os.Getenv(baselineUpdateEnv) != "",
+		known:   map[fingerprint]bool{},
+		found:   map[fingerprint]bool{},
+		checked: map[string]bool{},
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file := &baselineFile{}
+	if err := json.Unmarshal(content, file); err != nil {
+		return nil, fmt.Errorf("failed to read the baseline %s: %w", config.Path, err)
+	}
+	for _, violation := range file.Violations {
+		b.known[*violation] = true
+	}
+	return b, nil
+}
+
+// EmitIssue records the violations of the issue and reports it if any of them is new
+func (r *baselineRunner) EmitIssue(rule tflint.Rule, message string, issueRange hcl.Range) error {
+	fingerprints, err := issueFingerprints(r, rule, message, issueRange)
+	if err != nil {
+		return err
+	}
+
+	isNew := false
+	for _, fingerprint := range fingerprints {
+		r.baseline.found[fingerprint] = true
+		if !r.baseline.known[fingerprint] {
+			isNew = true
+		}
+	}
+
+	if r.baseline.update || !isNew {
+		return nil
+	}
+	return r.Runner.EmitIssue(rule, message, issueRange)
+}
+
+// Returns the fingerprints of the issue, one for every tag it is about
+func issueFingerprints(runner tflint.Runner, rule tflint.Rule, message string, issueRange hcl.Range) ([]fingerprint, error) {
+	files, err := runner.GetFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// Issues about instances are prefixed with their address, e.g. aws_s3_bucket.b["logs"]: ...
+	address := modules.AddressAt(files, issueRange)
+	if address != "" && strings.HasPrefix(message, address) {
+		if prefix, _, found := strings.Cut(message, ": "); found && !strings.Contains(prefix, " ") {
+			address = prefix
+		}
+	}
+	// Addresses in called modules include the path of the module, e.g. module.network.aws_vpc.main
+	prefix, err := modulePrefix(runner)
+	if err != nil {
+		return nil, err
+	}
+	address = strings.TrimSuffix(prefix+address, ".")
+
+	violation := utils.ViolationOf(rule)
+	if violation == nil {
+		return []fingerprint{{Rule: rule.Name(), Address: address}}, nil
+	}
+	if len(violation.Tags) == 0 {
+		return []fingerprint{{Rule: rule.Name(), Address: address, Kind: violation.Kind}}, nil
+	}
+
+	fingerprints := []fingerprint{}
+	for _, tag := range violation.Tags {
+		fingerprints = append(fingerprints, fingerprint{Rule: rule.Name(), Address: address, Kind: violation.Kind, Tag: tag})
+	}
+	return fingerprints, nil
+}
+
+// Records the violations of an exempted issue that are in the baseline as found, so their entries are neither reported as stale
+// nor dropped when updating the baseline while the exception holds
+func (b *baseline) keep(fingerprints []fingerprint) {
+	for _, fingerprint := range fingerprints {
+		if b.known[fingerprint] {
+			b.found[fingerprint] = true
+		}
+	}
+}
+
+// Returns the baseline entries of the rules that ran on the checked modules that no longer occur
+func (b *baseline) stale(rules []string) []fingerprint {
+	stale := []fingerprint{}
+	for _, fingerprint := range b.sorted(b.known) {
+		if slices.Contains(rules, fingerprint.Rule) && b.checked[addressModule(fingerprint.Address)] && !b.found[fingerprint] {
+			stale = append(stale, fingerprint)
+		}
+	}
+	return stale
+}
+
+// Writes the violations found during the run to the baseline file.
+// Entries of rules that didn't run and of modules that weren't checked are kept.
+func (b *baseline) write(rules []string) error {
+	violations := map[fingerprint]bool{}
+	for fingerprint := range b.known {
+		if !slices.Contains(rules, fingerprint.Rule) || !b.checked[addressModule(fingerprint.Address)] {
+			violations[fingerprint] = true
+		}
+	}
+	for fingerprint := range b.found {
+		violations[fingerprint] = true
+	}
+
+	file := &baselineFile{Violations: []*fingerprint{}}
+	for _, fingerprint := range b.sorted(violations) {
+		fingerprint := fingerprint
+		file.Violations = append(file.Violations, &fingerprint)
+	}
+
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(b.path, append(content, '\n'), 0o644)
+}
+
+// Returns the fingerprints sorted by rule, address, kind and tag, so the baseline file only changes with the violations
+func (b *baseline) sorted(fingerprints map[fingerprint]bool) []fingerprint {
+	sorted := []fingerprint{}
+	for fingerprint := range fingerprints {
+		sorted = append(sorted, fingerprint)
+	}
+	slices.SortFunc(sorted, func(a, b fingerprint) bool {
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Tag < b.Tag
+	})
+	return sorted
+}
+
+// Reports the stale baseline entries of the rules that ran once the root module is checked, or writes the baseline when updating it.
+// TFLint checks every module separately, so the baseline file is written again after every module. The stale entries of all checked
+// modules are reported on the root module, as TFLint drops issues of called modules that aren't on an argument of the module call.
+func (b *baseline) finish(runner tflint.Runner, rules []string) error {
+	path, err := runner.GetModulePath()
+	if err != nil {
+		return err
+	}
+	b.checked[path.String()] = true
+
+	if b.update {
+		return b.write(rules)
+	}
+
+	if !path.IsRoot() {
+		return nil
+	}
+	stale := b.stale(rules)
+	if len(stale) == 0 {
+		return nil
+	}
+	issueRange, err := moduleStart(runner)
+	if err != nil {
+		return err
+	}
+	for _, fingerprint := range stale {
+		err := runner.EmitIssue(&rulesetRule{name: "baseline", severity: tflint.NOTICE}, fmt.Sprintf("The baseline entry %s no longer occurs, remove it or update the baseline", fingerprint.String()), issueRange)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the path of the module the address is in, e.g. module.network for module.network.aws_vpc.main,
+// or "" for the root module, which module calls such as module.network are in
+func addressModule(address string) string {
+	module := []string{}
+	for strings.HasPrefix(address, "module.") {
+		name, rest, found := strings.Cut(strings.TrimPrefix(address, "module."), ".")
+		if !found {
+			break
+		}
+		module = append(module, "module."+name)
+		address = rest
+	}
+	return strings.Join(module, ".")
+}
+
+// Returns the fingerprint as it is named in messages, e.g. ensure_default_tags on aws_s3_bucket.b (missing_tags, "team")
+func (f fingerprint) String() string {
+	text := f.Rule
+	if f.Address != "" {
+		text += " on " + f.Address
+	}
+
+	details := []string{}
+	if f.Kind != "" {
+		details = append(details, f.Kind)
+	}
+	if f.Tag != "" {
+		details = append(details, fmt.Sprintf("\"%s\"", f.Tag))
+	}
+	if len(details) > 0 {
+		text += fmt.Sprintf(" (%s)", strings.Join(details, ", "))
+	}
+	return text
+}
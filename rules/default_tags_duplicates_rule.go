@@ -0,0 +1,121 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/project"
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"golang.org/x/exp/slices"
+)
+
+// DefaultTagsDuplicatesRule definition
+type DefaultTagsDuplicatesRule struct {
+	tflint.DefaultRule
+	utils.Violations
+}
+
+// DefaultTagsDuplicatesRuleConfig is a config of DefaultTagsDuplicatesRule
+type DefaultTagsDuplicatesRuleConfig struct {
+	AllowOverride []string `hclext:"allow_override,optional"`
+	Exclude       []string `hclext:"exclude,optional"`
+}
+
+// NewDefaultTagsDuplicatesRule returns a new rule
+func NewDefaultTagsDuplicatesRule() *DefaultTagsDuplicatesRule {
+	return &DefaultTagsDuplicatesRule{}
+}
+
+// Returns a copy of the rule with the violation of the issue it emits attached
+func (r *DefaultTagsDuplicatesRule) withViolation(kind string, tags ...string) tflint.Rule {
+	return &DefaultTagsDuplicatesRule{Violations: utils.NewViolations(kind, tags...)}
+}
+
+// Name returns the rule name
+func (r *DefaultTagsDuplicatesRule) Name() string {
+	return "default_tags_duplicates"
+}
+
+// Enabled returns whether the rule is enabled by default
+func (r *DefaultTagsDuplicatesRule) Enabled() bool {
+	return false
+}
+
+// Severity returns the rule severity
+func (r *DefaultTagsDuplicatesRule) Severity() tflint.Severity {
+	return tflint.WARNING
+}
+
+// Link returns the rule reference link
+func (r *DefaultTagsDuplicatesRule) Link() string {
+	return project.ReferenceLink(r.Name())
+}
+
+// Checks the rule
+func (r *DefaultTagsDuplicatesRule) Check(runner tflint.Runner) error {
+	config := &DefaultTagsDuplicatesRuleConfig{}
+	if err := runner.DecodeRuleConfig(r.Name(), config); err != nil {
+		return err
+	}
+
+	for _, provider := range tagging.Providers {
+		defaultTags, err := getDefaultTags(runner, provider)
+		if err != nil {
+			return err
+		}
+		// Without default tags there is nothing a resource could duplicate
+		if len(defaultTags) == 0 {
+			continue
+		}
+
+		resources, err := tagging.GetResources(runner, provider, config.Exclude)
+		if err != nil {
+			return err
+		}
+
+		for _, resource := range resources {
+			err := r.verifyNoDuplicates(runner, config, provider, inheritedTags(defaultTags, resource), resource)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Compares the resource tags to the provider default tags and reports keys that are set in both
+func (r *DefaultTagsDuplicatesRule) verifyNoDuplicates(runner tflint.Runner, config *DefaultTagsDuplicatesRuleConfig, provider tagging.Provider, defaultTags map[string]string, resource *tagging.Resource) error {
+	for _, tags := range resource.OwnTags() {
+		if !tags.Known() {
+			continue
+		}
+
+		for _, key := range utils.SortedKeys(tags.Values) {
+			defaultValue, exists := defaultTags[key]
+			if !exists {
+				continue
+			}
+
+			var message, kind string
+			switch {
+			case defaultValue == tags.Values[key]:
+				kind = "redundant_tag"
+				message = fmt.Sprintf("%s \"%s\" on %s is redundant, the provider %s already set it to \"%s\"", provider.Noun(), key, resource.Address(), defaultTagsName(provider), defaultValue)
+			case slices.Contains(config.AllowOverride, key):
+				continue
+			default:
+				kind = "overridden_tag"
+				message = fmt.Sprintf("%s \"%s\" on %s overrides the provider %s value \"%s\" with \"%s\"", provider.Noun(), key, resource.Address(), defaultTagsName(provider), defaultValue, tags.Values[key])
+			}
+
+			err := runner.EmitIssue(r.withViolation(kind, key), message, tags.KeyRange(key))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_DefaultTagsDuplicatesRule(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Content  string
+		Config   string
+		Expected helper.Issues
+	}{
+		{
+			Name: "Succeeds_ForResource_WithDistinctTags",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team = "platform-engineering",
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					name = "web"
+				}
+			}`,
+			Config: `
+			rule "default_tags_duplicates" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForResource_WithRedundantAndOverriddenTags",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team        = "platform-engineering",
+						environment = "prod",
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					team        = "platform-engineering"
+					environment = "staging"
+				}
+			}`,
+			Config: `
+			rule "default_tags_duplicates" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewDefaultTagsDuplicatesRule(),
+					Message: "Tag \"environment\" on aws_instance.ec2_instance overrides the provider default_tags value \"prod\" with \"staging\"",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 15, Column: 6},
+						End:      hcl.Pos{Line: 15, Column: 29},
+					},
+				},
+				{
+					Rule:    NewDefaultTagsDuplicatesRule(),
+					Message: "Tag \"team\" on aws_instance.ec2_instance is redundant, the provider default_tags already set it to \"platform-engineering\"",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 14, Column: 6},
+						End:      hcl.Pos{Line: 14, Column: 42},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForResource_WithAllowedOverride",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						environment = "prod",
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					environment = "staging"
+				}
+			}`,
+			Config: `
+			rule "default_tags_duplicates" {
+				enabled        = true
+				allow_override = ["environment"]
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Succeeds_ForResource_UsingProviderWithoutDefaultTags",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team = "platform-engineering",
+					}
+				}
+			}
+
+			provider "aws" {
+				alias  = "us"
+				region = "us-east-1"
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				provider = aws.us
+				tags = {
+					team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "default_tags_duplicates" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Succeeds_ForResource_WithRedundantTags_ButExcluded",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team = "platform-engineering",
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "default_tags_duplicates" {
+				enabled = true
+				exclude = ["aws_instance"]
+			}`,
+			Expected: helper.Issues{},
+		},
+	}
+
+	rule := NewDefaultTagsDuplicatesRule()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{"resource.tf": test.Content, ".tflint.hcl": test.Config})
+
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+
+			helper.AssertIssues(t, test.Expected, runner.Issues)
+		})
+	}
+}
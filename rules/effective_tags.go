@@ -0,0 +1,110 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/cache"
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// Evaluates the default tags of every configuration of the provider, keyed by provider alias ("" for the default provider).
+// Configurations without default tags or with default tags that cannot be evaluated are left out.
+func getDefaultTags(runner tflint.Runner, provider tagging.Provider) (map[string]*tagging.Tags, error) {
+	return cache.Get(runner, "rules.default-tags."+provider.Name(), func() (map[string]*tagging.Tags, error) {
+		return evaluateDefaultTags(runner, provider)
+	})
+}
+
+// Collects the default tags of the provider configurations that could be evaluated
+func evaluateDefaultTags(runner tflint.Runner, provider tagging.Provider) (map[string]*tagging.Tags, error) {
+	defaultTags := map[string]*tagging.Tags{}
+	if provider.DefaultTags() == nil {
+		return defaultTags, nil
+	}
+
+	providerConfigs, err := tagging.GetProviderConfigs(runner, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, providerConfig := range providerConfigs {
+		for _, tags := range providerConfig.DefaultTags {
+			if tags.Known() {
+				defaultTags[providerConfig.Alias] = tags
+			}
+		}
+	}
+
+	return defaultTags, nil
+}
+
+// Returns the evaluated default tags the resource inherits from its provider configuration
+func inheritedTags(defaultTags map[string]*tagging.Tags, resource *tagging.Resource) map[string]string {
+	if !resource.InheritsDefaultTags {
+		return map[string]string{}
+	}
+	if tags, exists := defaultTags[resource.ProviderAlias]; exists {
+		return tags.Values
+	}
+	return map[string]string{}
+}
+
+// Returns the tags the resource will carry once deployed, its own tags merged over the default tags of its provider configuration.
+// They are not known if the tags of the resource or the default tags could not be evaluated.
+func effectiveTags(runner tflint.Runner, provider tagging.Provider, resource *tagging.Resource) (map[string]string, bool, error) {
+	if !resource.Known() {
+		return nil, false, nil
+	}
+
+	if provider.DefaultTags() != nil && resource.InheritsDefaultTags {
+		providerConfigs, err := tagging.GetProviderConfigs(runner, provider)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, providerConfig := range providerConfigs {
+			if providerConfig.Alias != resource.ProviderAlias {
+				continue
+			}
+			for _, tags := range providerConfig.DefaultTags {
+				if !tags.Known() {
+					return nil, false, nil
+				}
+			}
+		}
+	}
+
+	defaultTags, err := getDefaultTags(runner, provider)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tags := map[string]string{}
+	for key, value := range inheritedTags(defaultTags, resource) {
+		tags[key] = value
+	}
+	for key, value := range resource.Values() {
+		tags[key] = value
+	}
+	return tags, true, nil
+}
+
+// Returns the key a tag is kept under on the resources of the provider.
+// Kubernetes labels can be mapped to a different key, e.g. team to app.kubernetes.io/team.
+func providerTagKey(provider tagging.Provider, key string, kubernetesLabelKeys map[string]string) string {
+	if provider != tagging.Kubernetes {
+		return key
+	}
+	if labelKey, exists := kubernetesLabelKeys[key]; exists {
+		return labelKey
+	}
+	return key
+}
+
+// Returns the message about the resource, naming the instance for resources using count or for_each as their instances share the same ranges
+func resourceMessage(resource *tagging.Resource, message string) string {
+	if resource == nil || !resource.IsInstance() {
+		return message
+	}
+	return fmt.Sprintf("%s: %s", resource.Address(), message)
+}
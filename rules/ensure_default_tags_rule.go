@@ -2,29 +2,36 @@ package rules
 
 import (
 	"fmt"
-	"reflect"
 	"strings"
 
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
 	"github.com/0north/tflint-ruleset-0north-plugin/utils"
-	"github.com/hashicorp/hcl/v2"
-	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
 	"github.com/terraform-linters/tflint-plugin-sdk/helper"
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 	"github.com/terraform-linters/tflint-ruleset-aws/project"
-	awsRules "github.com/terraform-linters/tflint-ruleset-aws/rules"
+	"github.com/zclconf/go-cty/cty"
+	"golang.org/x/exp/slices"
 )
 
-var config *EnsureDefaultTagsRuleConfig
-
 // EnsureDefaultTagsRule definition
 type EnsureDefaultTagsRule struct {
 	tflint.DefaultRule
+	utils.Violations
 }
 
 // EnsureDefaultTagsRuleConfig is a config of EnsureDefaultTagsRule
 type EnsureDefaultTagsRuleConfig struct {
-	Tags    []string `hclext:"tags"`
-	Exclude []string `hclext:"exclude,optional"`
+	Tags                []string             `hclext:"tags"`
+	ExcludeEntries      cty.Value            `hclext:"exclude,optional"`
+	KubernetesLabelKeys map[string]string    `hclext:"kubernetes_label_keys,optional"`
+	OnUnknown           string               `hclext:"on_unknown,optional"`
+	Conditions          []TagConditionConfig `hclext:"condition,block"`
+	Policies            []TagPolicyConfig    `hclext:"policy,block"`
+	PolicyVariables     cty.Value            `hclext:"policy_variables,optional"`
+	// Exclude holds the resource types excluded by the entries of exclude that haven't expired
+	Exclude    []string
+	conditions []*tagCondition
+	policies   []*tagPolicy
 }
 
 // NewEnsureDefaultTagsRule returns a new rule
@@ -32,6 +39,11 @@ func NewEnsureDefaultTagsRule() *EnsureDefaultTagsRule {
 	return &EnsureDefaultTagsRule{}
 }
 
+// Returns a copy of the rule with the violation of the issue it emits attached
+func (r *EnsureDefaultTagsRule) withViolation(kind string, tags ...string) tflint.Rule {
+	return &EnsureDefaultTagsRule{Violations: utils.NewViolations(kind, tags...)}
+}
+
 // Name returns the rule name
 func (r *EnsureDefaultTagsRule) Name() string {
 	return "ensure_default_tags"
@@ -54,145 +66,308 @@ func (r *EnsureDefaultTagsRule) Link() string {
 
 // Checks the rule
 func (r *EnsureDefaultTagsRule) Check(runner tflint.Runner) error {
-	config = &EnsureDefaultTagsRuleConfig{}
+	config := &EnsureDefaultTagsRuleConfig{}
 	if err := runner.DecodeRuleConfig(r.Name(), config); err != nil {
 		return err
 	}
-
-	// Check provider
-	providers, err := runner.GetProviderContent("aws", &hclext.BodySchema{
-		Blocks: []hclext.BlockSchema{
-			{
-				Type: "default_tags",
-				Body: &hclext.BodySchema{
-
-					Attributes: []hclext.AttributeSchema{
-						{
-							Name: "tags",
-						},
-					},
-				},
-			},
-		},
-	}, nil)
+	onUnknown, err := resolveOnUnknown(config.OnUnknown)
+	if err != nil {
+		return err
+	}
+	config.OnUnknown = onUnknown
+	config.Exclude, err = resolveExclude(runner, r, config.ExcludeEntries)
+	if err != nil {
+		return err
+	}
+	config.conditions, err = compileTagConditions(r.Name(), config.Conditions, false)
+	if err != nil {
+		return err
+	}
+	config.policies, err = compileTagPolicies(config.Policies, config.PolicyVariables)
 	if err != nil {
 		return err
 	}
 
-	// Go through all providers
-	providerDefaultTagIssues := []helper.Issue{}
-	for _, provider := range providers.Blocks {
-		// Check that default_tags is present on the provider
-		defaultTagsBlocks := provider.Body.Blocks.OfType("default_tags")
-		if len(defaultTagsBlocks) == 0 {
-			providerDefaultTagIssues = append(providerDefaultTagIssues, utils.NewIssue(r, "default_tags is missing", provider.DefRange))
-			continue
+	for _, provider := range tagging.Providers {
+		err := r.checkProvider(runner, config, provider)
+		if err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
 
-		// Check for required tags
-		for _, defaultTagsBlock := range defaultTagsBlocks {
-			r.verifyRequiredTags(defaultTagsBlock, runner)
+// Checks the provider configurations for default tags with the required tags, falling back to the tags of every resource when they are missing.
+// Providers without default tags always have their resources checked.
+func (r *EnsureDefaultTagsRule) checkProvider(runner tflint.Runner, config *EnsureDefaultTagsRuleConfig, provider tagging.Provider) error {
+	noun := strings.ToLower(provider.Noun()) + "s"
+	required := requiredKeys(config, provider)
+
+	providerIssues := []helper.Issue{}
+	defaultTags := map[string]map[string]string{}
+	if provider.DefaultTags() != nil {
+		providerConfigs, err := tagging.GetProviderConfigs(runner, provider)
+		if err != nil {
+			return err
+		}
+
+		for _, providerConfig := range providerConfigs {
+			// Check that default tags are present on the provider
+			if len(providerConfig.DefaultTags) == 0 {
+				providerIssues = append(providerIssues, utils.NewIssue(r.withViolation("missing_default_tags"), fmt.Sprintf("%s is missing", defaultTagsName(provider)), providerConfig.Block.DefRange))
+				continue
+			}
+
+			// Check for required tags
+			for _, tags := range providerConfig.DefaultTags {
+				if !tags.Known() {
+					err := emitUnknownTags(runner, r, config.OnUnknown, fmt.Sprintf("the %s provider", provider.Name()), tags)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+				defaultTags[providerConfig.Alias] = tags.Values
+
+				if missingTags := missingKeys(required, tags.Values); len(missingTags) > 0 {
+					err := runner.EmitIssue(
+						r.withViolation("missing_tags", missingTags...),
+						fmt.Sprintf("The provider is missing the following %s: %s.", noun, quoteKeys(missingTags)),
+						tags.ValueRange,
+					)
+					if err != nil {
+						return err
+					}
+				}
+			}
 		}
 	}
 
-	// If we have default provider tag issues, we now need to check for AWS tags on all resources
-	if len(providerDefaultTagIssues) != 0 {
-		// Here we inject our override methods into the runner in order to capture issues from the AWS rule
-		awsRunner := &AWSRunner{
-			Runner: runner,
-			Issues: []helper.Issue{},
+	// With default tags on every provider configuration the resources don't need to set the required tags themselves
+	checkResourceTags := provider.DefaultTags() == nil || len(providerIssues) > 0
+
+	resources, err := tagging.GetResources(runner, provider, config.Exclude)
+	if err != nil {
+		return err
+	}
+
+	resourceIssues := []helper.Issue{}
+	for _, resource := range resources {
+		// Standalone tags don't receive the default tags, so they are checked however the provider is configured
+		err := r.verifyStandaloneTags(runner, config, provider, resource)
+		if err != nil {
+			return err
 		}
 
-		// This runs the AWS Ressource Missing Tags Rule with our custom runner (so it uses our configuration and captures issues)
-		// https://github.com/terraform-linters/tflint-ruleset-aws/blob/master/docs/rules/aws_resource_missing_tags.md
-		err := awsRules.NewAwsResourceMissingTagsRule().Check(awsRunner)
+		// Tags required by a condition aren't required on the provider, so they are checked however it is configured
+		err = r.verifyConditions(runner, config, provider, resource)
 		if err != nil {
 			return err
 		}
 
-		// If there are issues with both missing default tags and missing resource tags, output all issues found
-		if len(providerDefaultTagIssues) > 0 && len(awsRunner.Issues) > 0 {
-			for _, issue := range providerDefaultTagIssues {
-				err := runner.EmitIssue(r, issue.Message, issue.Range)
+		err = verifyTagPolicies(runner, r, config.policies, provider, resource)
+		if err != nil {
+			return err
+		}
+
+		// The tags of resources that don't inherit default tags were checked with the standalone ones
+		if !checkResourceTags || !resource.InheritsDefaultTags {
+			continue
+		}
+		if !resource.Known() {
+			for _, tags := range resource.OwnTags() {
+				err := emitUnknownTags(runner, r, config.OnUnknown, resource.Address(), tags)
 				if err != nil {
 					return err
 				}
 			}
+			continue
+		}
 
-			for _, issue := range awsRunner.Issues {
-				err := runner.EmitIssue(r, issue.Message, issue.Range)
-				if err != nil {
-					return err
-				}
+		tags := resource.Values()
+		for key, value := range defaultTags[resource.ProviderAlias] {
+			tags[key] = value
+		}
+
+		if missingTags := missingKeys(required, tags); len(missingTags) > 0 {
+			slices.Sort(missingTags)
+			issueRange := resource.Block.DefRange
+			if ownTags := resource.OwnTags(); len(ownTags) > 0 {
+				issueRange = ownTags[0].ValueRange
 			}
+			resourceIssues = append(resourceIssues, utils.NewIssue(
+				r.withViolation("missing_tags", missingTags...),
+				resourceMessage(resource, fmt.Sprintf("The resource is missing the following %s: %s.", noun, quoteKeys(missingTags))),
+				issueRange,
+			))
 		}
+	}
 
+	// Missing default tags are only reported if there are resources missing tags as well
+	if len(resourceIssues) > 0 {
+		for _, issue := range append(providerIssues, resourceIssues...) {
+			err := runner.EmitIssue(issue.Rule, issue.Message, issue.Range)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
-// Takes a Terraform block and verifies that the block has all the required tags
-func (r *EnsureDefaultTagsRule) verifyRequiredTags(block *hclext.Block, runner tflint.Runner) {
-	tagsAttribute := block.Body.Attributes["tags"]
-	if tagsAttribute == nil {
-		return
+// Verifies that the resource has the tags required by the conditions its tags match
+func (r *EnsureDefaultTagsRule) verifyConditions(runner tflint.Runner, config *EnsureDefaultTagsRuleConfig, provider tagging.Provider, resource *tagging.Resource) error {
+	if len(config.conditions) == 0 {
+		return nil
+	}
+	tags, known, err := effectiveTags(runner, provider, resource)
+	if err != nil || !known {
+		return err
 	}
 
-	tagsBlock := tagsAttribute.Expr
-	tagAttributes, _ := tagsBlock.Value(&hcl.EvalContext{})
-
-	if !tagAttributes.Type().IsObjectType() {
-		return
+	issueRange := resource.Block.DefRange
+	if ownTags := resource.OwnTags(); len(ownTags) > 0 {
+		issueRange = ownTags[0].ValueRange
 	}
 
-	var missingTags []string = []string{}
-	attributeMap := tagAttributes.AsValueMap()
-	for _, requiredTag := range config.Tags {
-		found := false
-		for key := range attributeMap {
-			if key == requiredTag {
-				found = true
-				break
-			}
+	for _, condition := range config.conditions {
+		ifKey, thenKey := condition.keys(provider, config.KubernetesLabelKeys)
+		reason, applies := condition.applies(tags, ifKey)
+		if !applies {
+			continue
+		}
+		if _, exists := tags[thenKey]; exists {
+			continue
 		}
-		if !found {
-			missingTags = append(missingTags, requiredTag)
+
+		err := runner.EmitIssue(
+			r.withViolation("missing_conditional_tag", thenKey),
+			resourceMessage(resource, fmt.Sprintf("The resource is missing the %s \"%s\", which is required as %s.", strings.ToLower(provider.Noun()), thenKey, reason)),
+			issueRange,
+		)
+		if err != nil {
+			return err
 		}
 	}
 
-	if len(missingTags) > 0 {
+	return nil
+}
+
+// Verifies that the standalone tags of the resource, such as those of its volumes, have the required tags,
+// and that there are tags for every required type, such as the instance and volume tag_specifications of launch templates
+func (r *EnsureDefaultTagsRule) verifyStandaloneTags(runner tflint.Runner, config *EnsureDefaultTagsRuleConfig, provider tagging.Provider, resource *tagging.Resource) error {
+	noun := strings.ToLower(provider.Noun()) + "s"
+	required := requiredKeys(config, provider)
+
+	// Resources that don't inherit default tags, such as autoscaling groups, need tags at one of their locations
+	if !resource.InheritsDefaultTags && len(resource.Tags) == 0 && len(required) > 0 {
+		missingTags := slices.Clone(required)
+		slices.Sort(missingTags)
 		err := runner.EmitIssue(
-			r,
-			fmt.Sprintf("The provider is missing the following tags: %s.", "\""+strings.Join(missingTags, "\", "+"\"")+"\""),
-			tagsBlock.Range(),
+			r.withViolation("missing_tags", missingTags...),
+			resourceMessage(resource, fmt.Sprintf("The resource is missing the following %s: %s.", noun, quoteKeys(missingTags))),
+			resource.Block.DefRange,
 		)
 		if err != nil {
-			return
+			return err
 		}
 	}
+
+	for _, tags := range resource.Tags {
+		if !tags.Location.Standalone {
+			continue
+		}
+		if !tags.Known() {
+			err := emitUnknownTags(runner, r, config.OnUnknown, resource.Address(), tags)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if missingTags := missingKeys(required, tags.Values); len(missingTags) > 0 {
+			slices.Sort(missingTags)
+			location := tags.Location.String()
+			if tags.Type != "" {
+				location += fmt.Sprintf(" (%s \"%s\")", tags.Location.TypeAttribute, tags.Type)
+			}
+			described := location
+			if tags.Location.Format == tagging.BlockFormat {
+				described = tags.Location.String() + " blocks"
+			}
+			err := runner.EmitIssue(
+				r.withViolation("missing_tags:"+location, missingTags...),
+				resourceMessage(resource, fmt.Sprintf("The %s of the resource are missing the following %s: %s.", described, noun, quoteKeys(missingTags))),
+				tags.ValueRange,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, location := range provider.ResourceTags(resource.Type) {
+		if len(location.RequiredTypes) == 0 {
+			continue
+		}
+
+		missingTypes := []string{}
+		for _, requiredType := range location.RequiredTypes {
+			found := slices.ContainsFunc(resource.Tags, func(tags *tagging.Tags) bool {
+				return tags.Location.String() == location.String() && tags.Type == requiredType
+			})
+			if !found {
+				missingTypes = append(missingTypes, requiredType)
+			}
+		}
+
+		if len(missingTypes) > 0 {
+			err := runner.EmitIssue(
+				r.withViolation("missing_tag_types:"+location.String(), missingTypes...),
+				resourceMessage(resource, fmt.Sprintf("The resource is missing %s for the following %s values: %s.", location.String(), location.TypeAttribute, quoteKeys(missingTypes))),
+				resource.Block.DefRange,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
-// AWS runner overrides EmitIssue and DecodeRuleConfig to wrap AwsResourceMissingTagsRule. This allows us to capture the issues it finds as well as use our own configuration
-type AWSRunner struct {
-	tflint.Runner
-	Issues []helper.Issue
+// Returns how the default tags of the provider are referred to in messages, e.g. default_tags
+func defaultTagsName(provider tagging.Provider) string {
+	location := provider.DefaultTags()
+	if len(location.Blocks) > 0 {
+		return location.Blocks[0]
+	}
+	return location.Name
 }
 
-func (r *AWSRunner) EmitIssue(rule tflint.Rule, message string, issueRange hcl.Range) error {
-	r.Issues = append(r.Issues, utils.NewIssue(rule, message, issueRange))
-	return nil
+// Quotes and joins the keys for use in a message
+func quoteKeys(keys []string) string {
+	return "\"" + strings.Join(keys, "\", \"") + "\""
 }
 
-func (r *AWSRunner) DecodeRuleConfig(ruleName string, ret interface{}) error {
-	v := reflect.ValueOf(ret).Elem()
-	v.Set(reflect.ValueOf(struct {
-		Tags    []string `hclext:"tags"`
-		Exclude []string `hclext:"exclude,optional"`
-	}{
-		Tags:    config.Tags,
-		Exclude: config.Exclude,
-	}))
+// Returns the keys the required tags are kept under on the resources of the provider
+func requiredKeys(config *EnsureDefaultTagsRuleConfig, provider tagging.Provider) []string {
+	keys := []string{}
+	for _, tag := range config.Tags {
+		keys = append(keys, providerTagKey(provider, tag, config.KubernetesLabelKeys))
+	}
+	return keys
+}
 
-	return nil
+// Returns the required keys that are not present, in the order they are required in
+func missingKeys(required []string, present map[string]string) []string {
+	missing := []string{}
+	for _, key := range required {
+		if _, exists := present[key]; !exists {
+			missing = append(missing, key)
+		}
+	}
+	return missing
 }
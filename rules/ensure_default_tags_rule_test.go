@@ -2,9 +2,13 @@ package rules
 
 import (
 	"testing"
+	"time"
 
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
 	hcl "github.com/hashicorp/hcl/v2"
 	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+	"github.com/terraform-linters/tflint-plugin-sdk/terraform/addrs"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
 func Test_EnsureDefaultTagsRule(t *testing.T) {
@@ -140,13 +144,586 @@ func Test_EnsureDefaultTagsRule(t *testing.T) {
 			}`,
 			Expected: helper.Issues{},
 		},
+		{
+			Name: "Succeeds_ForResource_WithTagsFromAliasedProvider",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+			}
+
+			provider "aws" {
+				alias  = "tagged"
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team = "platform"
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				provider = aws.tagged
+			}
+			  `,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForAutoscalingGroup_WithTagBlocksMissingTags",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+			}
+
+			resource "aws_autoscaling_group" "asg" {
+				tag {
+					key                 = "application"
+					value               = "web"
+					propagate_at_launch = true
+				}
+			}
+			  `,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The tag blocks of the resource are missing the following tags: \"team\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 7, Column: 5},
+						End:      hcl.Pos{Line: 7, Column: 8},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForAutoscalingGroup_WithOnlyDefaultTags",
+			Content: `
+			provider "aws" {
+				default_tags {
+					tags = {
+						team = "platform"
+					}
+				}
+			}
+
+			resource "aws_autoscaling_group" "asg" {
+				max_size = 1
+			}
+			  `,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The resource is missing the following tags: \"team\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 10, Column: 4},
+						End:      hcl.Pos{Line: 10, Column: 42},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForAutoscalingGroup_WithTagsList",
+			Content: `
+			provider "aws" {
+				default_tags {
+					tags = {
+						team = "platform"
+					}
+				}
+			}
+
+			resource "aws_autoscaling_group" "asg" {
+				tags = [
+					{
+						key                 = "team"
+						value               = "platform"
+						propagate_at_launch = true
+					},
+				]
+			}
+			  `,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForInstance_WithRootBlockDeviceTagsMissing",
+			Content: `
+			provider "aws" {
+				default_tags {
+					tags = {
+						team = "platform"
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				root_block_device {
+					tags = {
+						Name = "root"
+					}
+				}
+			}
+			  `,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The root_block_device.tags of the resource are missing the following tags: \"team\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 12, Column: 13},
+						End:      hcl.Pos{Line: 14, Column: 7},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForLaunchTemplate_WithVolumeTagSpecificationMissing",
+			Content: `
+			provider "aws" {
+				default_tags {
+					tags = {
+						team = "platform"
+					}
+				}
+			}
+
+			resource "aws_launch_template" "template" {
+				tag_specifications {
+					resource_type = "instance"
+					tags = {
+						team = "platform"
+					}
+				}
+			}
+			  `,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The resource is missing tag_specifications.tags for the following resource_type values: \"volume\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 10, Column: 4},
+						End:      hcl.Pos{Line: 10, Column: 45},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForGoogleProvider_WithLabelsPresent",
+			Content: `
+			provider "google" {
+				project = "zeronorth"
+				default_labels = {
+					team = "platform-engineering"
+				}
+			}
+
+			resource "google_storage_bucket" "bucket" {
+				name = "logs"
+			}`,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForGoogleProvider_WithLabelsMissing",
+			Content: `
+			provider "google" {
+				project = "zeronorth"
+				default_labels = {
+					team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team", "application"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The provider is missing the following labels: \"application\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 4, Column: 22},
+						End:      hcl.Pos{Line: 6, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForGoogleResource_WithLabelsMissing",
+			Content: `
+			provider "google" {
+				project = "zeronorth"
+			}
+
+			resource "google_storage_bucket" "bucket" {
+				name = "logs"
+				labels = {
+					application = "logging"
+				}
+			}
+
+			resource "google_pubsub_topic" "topic" {
+				name = "events"
+				labels = {
+					team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "default_labels is missing",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 2, Column: 4},
+						End:      hcl.Pos{Line: 2, Column: 21},
+					},
+				},
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The resource is missing the following labels: \"team\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 8, Column: 14},
+						End:      hcl.Pos{Line: 10, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForAzurermResource_WithTagsMissing",
+			Content: `
+			resource "azurerm_resource_group" "group" {
+				name     = "platform"
+				location = "West Europe"
+				tags = {
+					team = "platform-engineering"
+				}
+			}
+
+			resource "azurerm_storage_account" "storage" {
+				name = "logs"
+			}`,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The resource is missing the following tags: \"team\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 10, Column: 4},
+						End:      hcl.Pos{Line: 10, Column: 48},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForAzurermResource_WithTagsMissing_ButExcluded",
+			Content: `
+			resource "azurerm_storage_account" "storage" {
+				name = "logs"
+			}`,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			  exclude	= ["azurerm_storage_account"]
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForKubernetesResource_WithMappedLabelMissing",
+			Content: `
+			resource "kubernetes_namespace_v1" "namespace" {
+				metadata {
+					name = "payments"
+					labels = {
+						team = "payments"
+					}
+				}
+			}`,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			  kubernetes_label_keys = {
+			    team = "example.com/team"
+			  }
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The resource is missing the following labels: \"example.com/team\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 15},
+						End:      hcl.Pos{Line: 7, Column: 7},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForForEachResource_WithTagsMissingOnOneInstance",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+			}
+
+			resource "aws_s3_bucket" "b" {
+				for_each = {
+					logs = { team = "platform-engineering" }
+					data = {}
+				}
+				tags = each.value
+			}
+			  `,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "default_tags is missing",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 2, Column: 4},
+						End:      hcl.Pos{Line: 2, Column: 18},
+					},
+				},
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "aws_s3_bucket.b[\"data\"]: The resource is missing the following tags: \"team\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 11, Column: 12},
+						End:      hcl.Pos{Line: 11, Column: 22},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForResource_WithUnknownTags_AsNotice",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+			}
+
+			resource "aws_s3_bucket" "b" {
+				tags = aws_s3_bucket.other.tags
+			}
+			  `,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled    = true
+			  tags		 = ["team"]
+			  on_unknown = "notice"
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    utils.WithSeverity(NewEnsureDefaultTagsRule(), tflint.NOTICE),
+					Message: "The tags of aws_s3_bucket.b could not be verified, as aws_s3_bucket.other.tags could not be evaluated",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 7, Column: 5},
+						End:      hcl.Pos{Line: 7, Column: 36},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForResource_WithConditionalTagMissing",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team        = "platform-engineering"
+						environment = "prod"
+					}
+				}
+			}
+
+			resource "aws_s3_bucket" "logs" {
+				tags = {
+					name = "logs"
+				}
+			}
+
+			resource "aws_s3_bucket" "data" {
+				tags = {
+					data-classification = "confidential"
+				}
+			}
+
+			resource "aws_s3_bucket" "scratch" {
+				tags = {
+					environment = "dev"
+				}
+			}
+
+			resource "aws_sqs_queue" "jobs" {
+				tags = {
+					environment = "production"
+				}
+			}`,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled = true
+			  tags    = ["team"]
+
+			  condition "prod-classification" {
+			    if_tag     = "environment"
+			    if_matches = "prod|production"
+			    then_tag   = "data-classification"
+			  }
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The resource is missing the tag \"data-classification\", which is required as \"environment\" is \"prod\", matching \"prod|production\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 13, Column: 12},
+						End:      hcl.Pos{Line: 15, Column: 6},
+					},
+				},
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The resource is missing the tag \"data-classification\", which is required as \"environment\" is \"production\", matching \"prod|production\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 31, Column: 12},
+						End:      hcl.Pos{Line: 33, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForResource_WithPolicyNotSatisfied_ByDefaultTags",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team = "platform-engineering"
+						env  = "prod"
+					}
+				}
+			}
+
+			resource "aws_s3_bucket" "logs" {
+			}
+
+			resource "aws_s3_bucket" "data" {
+				tags = {
+					oncall = "platform-pager"
+				}
+			}
+
+			resource "aws_sqs_queue" "jobs" {
+				tags = {
+					env = "dev"
+				}
+			}`,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled = true
+			  tags    = ["team"]
+
+			  policy "prod-oncall" {
+			    condition = <<-EOT
+			      resource.type != "aws_s3_bucket" || tags.env != "prod" || can(tags.oncall)
+			    EOT
+			    message   = "Production buckets need an oncall tag."
+			    severity  = "notice"
+			  }
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    utils.WithSeverity(NewEnsureDefaultTagsRule(), tflint.NOTICE),
+					Message: "Production buckets need an oncall tag.",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 12, Column: 4},
+						End:      hcl.Pos{Line: 12, Column: 35},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForResource_WithConditionNotMatching",
+			Content: `
+			resource "azurerm_storage_account" "sa" {
+				tags = {
+					team      = "platform-engineering"
+					temporary = "false"
+				}
+			}`,
+			Config: `
+			rule "ensure_default_tags" {
+			  enabled = true
+			  tags    = ["team"]
+
+			  condition "temporary-expiry" {
+			    if_tag     = "temporary"
+			    if_matches = "true"
+			    then_tag   = "expires-on"
+			  }
+			}`,
+			Expected: helper.Issues{},
+		},
 	}
 
 	rule := NewEnsureDefaultTagsRule()
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
-			runner := helper.TestRunner(t, map[string]string{"resource.tf": test.Content, ".tflint.hcl": test.Config})
+			runner := newTestRunner(t, map[string]string{"resource.tf": test.Content, ".tflint.hcl": test.Config})
 
 			if err := rule.Check(runner); err != nil {
 				t.Fatalf("Unexpected error occurred: %s", err)
@@ -156,3 +733,138 @@ func Test_EnsureDefaultTagsRule(t *testing.T) {
 		})
 	}
 }
+
+func Test_EnsureDefaultTagsRule_CalledModule(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Root     string
+		Expected helper.Issues
+	}{
+		{
+			Name: "Fails_ForModuleResource_WithTagsMissing",
+			Root: `
+			provider "aws" {
+				region = "eu-west-1"
+			}
+
+			module "network" {
+				source = "./modules/network"
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "module.network.aws_vpc.main: The resource is missing the following tags: \"team\".",
+					Range: hcl.Range{
+						Filename: "main.tf",
+						Start:    hcl.Pos{Line: 6, Column: 4},
+						End:      hcl.Pos{Line: 6, Column: 20},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForModuleResource_WithDefaultTagsOfPassedProvider",
+			Root: `
+			provider "aws" {
+				region = "eu-west-1"
+			}
+
+			provider "aws" {
+				alias = "tagged"
+				default_tags {
+					tags = {
+						team = "platform-engineering"
+					}
+				}
+			}
+
+			module "network" {
+				source = "./modules/network"
+				providers = {
+					aws = aws.tagged
+				}
+			}`,
+			Expected: helper.Issues{},
+		},
+	}
+
+	rule := NewEnsureDefaultTagsRule()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			issues := checkCalledModule(t, rule, addrs.Module{"network"}, map[string]string{"main.tf": test.Root}, map[string]string{
+				"modules/network/main.tf": `
+			resource "aws_vpc" "main" {
+				cidr_block = "10.0.0.0/16"
+				tags = {
+					Name = "main"
+				}
+			}`,
+				".tflint.hcl": `
+			rule "ensure_default_tags" {
+			  enabled   = true
+			  tags		= ["team"]
+			}`,
+			})
+
+			helper.AssertIssues(t, test.Expected, issues)
+		})
+	}
+}
+
+func Test_EnsureDefaultTagsRule_ExcludeUntil(t *testing.T) {
+	today := now
+	now = func() time.Time { return time.Date(2026, 6, 15, 12, 0, 0, 0, time.Local) }
+	t.Cleanup(func() { now = today })
+
+	config := `rule "ensure_default_tags" {
+  enabled = true
+  tags    = ["team"]
+  exclude = [
+    "azurerm_resource_group",
+    { resource = "azurerm_storage_account", reason = "Tagged by the data platform", until = "2026-06-14" },
+    { resource = "azurerm_virtual_network", until = "2026-06-15" },
+  ]
+}`
+
+	runner := newTestRunner(t, map[string]string{
+		"resource.tf": `
+resource "azurerm_resource_group" "rg" {
+  tags = {}
+}
+
+resource "azurerm_storage_account" "sa" {
+  tags = {}
+}
+
+resource "azurerm_virtual_network" "vnet" {
+  tags = {}
+}`,
+		".tflint.hcl": config,
+	})
+
+	if err := NewEnsureDefaultTagsRule().Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    utils.WithSeverity(NewEnsureDefaultTagsRule(), tflint.WARNING),
+			Message: "The exclusion of azurerm_storage_account expired on 2026-06-14 (Tagged by the data platform), remove it from exclude",
+			Range: hcl.Range{
+				Filename: ".tflint.hcl",
+				Start:    hcl.Pos{Line: 6, Column: 5},
+				End:      hcl.Pos{Line: 6, Column: 107},
+			},
+		},
+		{
+			Rule:    NewEnsureDefaultTagsRule(),
+			Message: "The resource is missing the following tags: \"team\".",
+			Range: hcl.Range{
+				Filename: "resource.tf",
+				Start:    hcl.Pos{Line: 7, Column: 10},
+				End:      hcl.Pos{Line: 7, Column: 12},
+			},
+		},
+	}, runner.Issues)
+}
@@ -0,0 +1,297 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/modules"
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"golang.org/x/exp/slices"
+)
+
+// Marks a comment or tag as an exception, followed by its fields, e.g.
+// # 0north:tag-exception rule=ensure_default_tags tag=team expires=2026-12-31 reason=Moves to the data platform in Q4
+const exceptionMarker = tagging.ExceptionKey
+
+// The layout of the expiry date of exceptions
+const exceptionDateLayout = "2006-01-02"
+
+// now returns the current time, exceptions and exclusions expire after the day of their expiry date
+var now = time.Now
+
+// exception exempts the violations of a rule on a resource from being reported until it expires
+type exception struct {
+	Rule string
+	// Tags are the exempted tag keys, every violation of the rule on the resource is exempted if there are none
+	Tags    []string
+	Expires time.Time
+	Reason  string
+	// Address of the resource, including the path of the module it is in, e.g. module.storage.aws_s3_bucket.logs
+	Address string
+}
+
+// exceptionRunner reports only the issues with violations that aren't exempted
+type exceptionRunner struct {
+	tflint.Runner
+	exceptions []*exception
+	// baseline is the baseline of the run, if any, which exempted issues don't reach
+	baseline *baseline
+}
+
+// Collects the exceptions of the module for the rules of the ruleset. Exceptions only apply to the module they are written in.
+// Exceptions that are invalid are reported instead, expired ones as an error of the rule they are for if it is enabled.
+func collectExceptions(runner tflint.Runner, rules []tflint.Rule, enabled []tflint.Rule) ([]*exception, error) {
+	prefix, err := modulePrefix(runner)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations, err := getExceptionAnnotations(runner)
+	if err != nil {
+		return nil, err
+	}
+
+	exceptions := []*exception{}
+	for _, annotation := range annotations {
+		exception, err := parseException(annotation.text)
+		switch {
+		case err != nil:
+		case annotation.address == "":
+			err = fmt.Errorf("it is not in or above a resource")
+		case ruleNamed(rules, exception.Rule) == nil:
+			err = fmt.Errorf("there is no rule \"%s\"", exception.Rule)
+		}
+		if err != nil {
+			err := runner.EmitIssue(
+				&rulesetRule{name: "tag_exception", severity: tflint.ERROR},
+				fmt.Sprintf("The exception is invalid, %s", err),
+				annotation.issueRange,
+			)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		exception.Address = prefix + annotation.address
+		if exception.expired() {
+			rule := ruleNamed(enabled, exception.Rule)
+			if rule == nil {
+				continue
+			}
+			issueRule := rule
+			if rule, ok := rule.(violationRule); ok {
+				issueRule = rule.withViolation("expired_exception", exception.Tags...)
+			}
+			err := runner.EmitIssue(
+				utils.WithSeverity(issueRule, tflint.ERROR),
+				fmt.Sprintf("The exception%s on %s expired on %s (%s)", exception.tagsText(), annotation.address, exception.Expires.Format(exceptionDateLayout), exception.Reason),
+				annotation.issueRange,
+			)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		exceptions = append(exceptions, exception)
+	}
+	return exceptions, nil
+}
+
+// exceptionAnnotation is the text of an exception, with the address it is on and where it is written
+type exceptionAnnotation struct {
+	text       string
+	address    string
+	issueRange hcl.Range
+}
+
+// Returns the exceptions of a single module, written in comments and in tags of taggable resources
+func getExceptionAnnotations(runner tflint.Runner) ([]*exceptionAnnotation, error) {
+	files, err := runner.GetFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := []*exceptionAnnotation{}
+	for filename, file := range files {
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+
+		tokens, _ := hclsyntax.LexConfig(file.Bytes, filename, hcl.InitialPos)
+		for _, token := range tokens {
+			if token.Type != hclsyntax.TokenComment {
+				continue
+			}
+			text := commentText(token.Bytes)
+			if !strings.HasPrefix(text, exceptionMarker) {
+				continue
+			}
+
+			// A comment is about the block it is in or the block following it
+			address := ""
+			for _, block := range body.Blocks {
+				if block.Range().ContainsPos(token.Range.Start) || block.Range().Start.Byte >= token.Range.End.Byte {
+					address = modules.AddressAt(files, block.DefRange())
+					break
+				}
+			}
+			annotations = append(annotations, &exceptionAnnotation{text: strings.TrimPrefix(text, exceptionMarker), address: address, issueRange: token.Range})
+		}
+	}
+
+	for _, provider := range tagging.Providers {
+		resources, err := tagging.GetResources(runner, provider, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range resources {
+			for _, tags := range resource.OwnTags() {
+				if tags.Exception != "" {
+					annotations = append(annotations, &exceptionAnnotation{text: tags.Exception, address: resource.Address(), issueRange: tags.KeyRange(exceptionMarker)})
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(annotations, func(a, b *exceptionAnnotation) bool {
+		if a.issueRange.Filename != b.issueRange.Filename {
+			return a.issueRange.Filename < b.issueRange.Filename
+		}
+		return a.issueRange.Start.Byte < b.issueRange.Start.Byte
+	})
+	return annotations, nil
+}
+
+// Returns the text of a comment without its delimiters
+func commentText(comment []byte) string {
+	text := strings.TrimSpace(string(comment))
+	for _, prefix := range []string{"#", "//", "/*"} {
+		text = strings.TrimPrefix(text, prefix)
+	}
+	return strings.TrimSpace(strings.TrimSuffix(text, "*/"))
+}
+
+// Parses the fields of an exception. The reason is the last field and takes the rest of the text, so it can contain spaces.
+func parseException(text string) (*exception, error) {
+	e := &exception{}
+	fields := strings.TrimSpace(text)
+	for fields != "" {
+		field, rest, _ := strings.Cut(fields, " ")
+		key, value, found := strings.Cut(field, "=")
+		if !found {
+			return nil, fmt.Errorf("expected a field such as rule=<name>, got \"%s\"", field)
+		}
+
+		switch key {
+		case "rule":
+			e.Rule = value
+		case "tag":
+			e.Tags = append(e.Tags, value)
+		case "expires":
+			expires, err := time.Parse(exceptionDateLayout, value)
+			if err != nil {
+				return nil, fmt.Errorf("expires should be a date such as 2026-12-31, got \"%s\"", value)
+			}
+			e.Expires = expires
+		case "reason":
+			e.Reason = strings.TrimSpace(strings.TrimPrefix(fields, "reason="))
+			rest = ""
+		default:
+			return nil, fmt.Errorf("unknown field \"%s\", valid fields are rule, tag, expires and reason", key)
+		}
+		fields = strings.TrimSpace(rest)
+	}
+
+	switch {
+	case e.Rule == "":
+		return nil, fmt.Errorf("rule is missing")
+	case e.Expires.IsZero():
+		return nil, fmt.Errorf("expires is missing")
+	case e.Reason == "":
+		return nil, fmt.Errorf("reason is missing")
+	}
+	return e, nil
+}
+
+// Returns whether the day of the expiry date has passed
+func (e *exception) expired() bool {
+	return expired(e.Expires)
+}
+
+// Returns whether the day of the date has passed
+func expired(date time.Time) bool {
+	expiry := time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, time.Local)
+	return !now().Before(expiry)
+}
+
+// Returns whether the exception exempts the violation
+func (e *exception) exempts(violation fingerprint) bool {
+	if violation.Rule != e.Rule {
+		return false
+	}
+	if violation.Address != e.Address && !strings.HasPrefix(violation.Address, e.Address+"[") {
+		return false
+	}
+	return len(e.Tags) == 0 || slices.Contains(e.Tags, violation.Tag)
+}
+
+// Returns what the exception is for as it is named in messages, e.g. for "team", "env"
+func (e *exception) tagsText() string {
+	if len(e.Tags) == 0 {
+		return fmt.Sprintf(" for %s", e.Rule)
+	}
+	return fmt.Sprintf(" for %s", quoteKeys(e.Tags))
+}
+
+// EmitIssue reports the issue unless every one of its violations is exempted
+func (r *exceptionRunner) EmitIssue(rule tflint.Rule, message string, issueRange hcl.Range) error {
+	if len(r.exceptions) == 0 {
+		return r.Runner.EmitIssue(rule, message, issueRange)
+	}
+
+	fingerprints, err := issueFingerprints(r, rule, message, issueRange)
+	if err != nil {
+		return err
+	}
+
+	for _, fingerprint := range fingerprints {
+		exempted := slices.ContainsFunc(r.exceptions, func(e *exception) bool { return e.exempts(fingerprint) })
+		if !exempted {
+			return r.Runner.EmitIssue(rule, message, issueRange)
+		}
+	}
+	if r.baseline != nil {
+		r.baseline.keep(fingerprints)
+	}
+	return nil
+}
+
+// Returns the rule with the name, or nil if there is none
+func ruleNamed(rules []tflint.Rule, name string) tflint.Rule {
+	for _, rule := range rules {
+		if rule.Name() == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Returns the prefix of the addresses in the module, e.g. module.storage., or "" for the root module
+func modulePrefix(runner tflint.Runner) (string, error) {
+	path, err := runner.GetModulePath()
+	if err != nil {
+		return "", err
+	}
+	if path.IsRoot() {
+		return "", nil
+	}
+	return path.String() + ".", nil
+}
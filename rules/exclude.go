@@ -0,0 +1,143 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/terraform-linters/tflint-plugin-sdk/plugin/fromproto"
+	"github.com/terraform-linters/tflint-plugin-sdk/plugin/plugin2host"
+	"github.com/terraform-linters/tflint-plugin-sdk/plugin/proto"
+	"github.com/terraform-linters/tflint-plugin-sdk/plugin/toproto"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ruleConfigRunner is a runner that returns the content of a rule config together with its ranges, which DecodeRuleConfig leaves out
+type ruleConfigRunner interface {
+	GetRuleConfigContent(name string, schema *hclext.BodySchema) (*hclext.BodyContent, error)
+}
+
+// Returns the content of the config of the rule with the schema, or nil if the runner can't return it.
+// The SDK only asks TFLint for the content of a rule config to decode it, so the runner of TFLint is asked directly.
+func getRuleConfigContent(runner tflint.Runner, name string, schema *hclext.BodySchema) (*hclext.BodyContent, error) {
+	switch runner := runner.(type) {
+	case ruleConfigRunner:
+		return runner.GetRuleConfigContent(name, schema)
+	case *plugin2host.GRPCClient:
+		resp, err := runner.Client.GetRuleConfigContent(context.Background(), &proto.GetRuleConfigContent_Request{
+			Name:   name,
+			Schema: toproto.BodySchema(schema),
+		})
+		if err != nil {
+			return nil, fromproto.Error(err)
+		}
+		content, diags := fromproto.BodyContent(resp.Content)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		return content, nil
+	}
+	return nil, nil
+}
+
+// Resolves the exclude list of the rule config to the resource types that are excluded. Entries are either a resource type,
+// excluded for good, or an object with the resource type, a reason and the last day it is excluded until:
+//
+//	exclude = ["aws_autoscaling_group", { resource = "aws_s3_bucket", reason = "Tagged by the data platform", until = "2026-12-31" }]
+//
+// Entries past their date are left out and reported.
+func resolveExclude(runner tflint.Runner, rule violationRule, exclude cty.Value) ([]string, error) {
+	resources := []string{}
+	if exclude == cty.NilVal || exclude.IsNull() {
+		return resources, nil
+	}
+	if !exclude.CanIterateElements() || exclude.Type().IsMapType() || exclude.Type().IsObjectType() {
+		return nil, fmt.Errorf("exclude should be a list of resource types and objects, got %s", exclude.Type().FriendlyName())
+	}
+
+	index := 0
+	for it := exclude.ElementIterator(); it.Next(); index++ {
+		_, entry := it.Element()
+		if entry.Type() == cty.String && !entry.IsNull() {
+			resources = append(resources, entry.AsString())
+			continue
+		}
+
+		resource, reason, until, err := decodeExcludeEntry(entry)
+		if err != nil {
+			return nil, fmt.Errorf("exclude entry %d is invalid, %w", index+1, err)
+		}
+		if until.IsZero() || !expired(until) {
+			resources = append(resources, resource)
+			continue
+		}
+
+		message := fmt.Sprintf("The exclusion of %s expired on %s, remove it from exclude", resource, until.Format(exceptionDateLayout))
+		if reason != "" {
+			message = fmt.Sprintf("The exclusion of %s expired on %s (%s), remove it from exclude", resource, until.Format(exceptionDateLayout), reason)
+		}
+		issueRange, err := excludeEntryRange(runner, rule.Name(), index)
+		if err != nil {
+			return nil, err
+		}
+		err = runner.EmitIssue(utils.WithSeverity(rule.withViolation("expired_exclusion:"+resource), tflint.WARNING), message, issueRange)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resources, nil
+}
+
+// Returns the range of the exclude entry at the index in the config of the rule, or of the whole exclude attribute if the
+// entries aren't written as a list
+func excludeEntryRange(runner tflint.Runner, name string, index int) (hcl.Range, error) {
+	content, err := getRuleConfigContent(runner, name, &hclext.BodySchema{Attributes: []hclext.AttributeSchema{{Name: "exclude"}}})
+	if err != nil || content == nil {
+		return hcl.Range{}, err
+	}
+	attribute, exists := content.Attributes["exclude"]
+	if !exists {
+		return hcl.Range{}, nil
+	}
+	if tuple, ok := attribute.Expr.(*hclsyntax.TupleConsExpr); ok && index < len(tuple.Exprs) {
+		return tuple.Exprs[index].Range(), nil
+	}
+	return attribute.Expr.Range(), nil
+}
+
+// Decodes an exclude entry given as an object. The resource is required, the reason and the date it is excluded until are optional.
+func decodeExcludeEntry(entry cty.Value) (resource string, reason string, until time.Time, err error) {
+	if !entry.Type().IsObjectType() || entry.IsNull() {
+		return "", "", time.Time{}, fmt.Errorf("expected a resource type or an object, got %s", entry.Type().FriendlyName())
+	}
+
+	for name, value := range entry.AsValueMap() {
+		if value.Type() != cty.String || value.IsNull() {
+			return "", "", time.Time{}, fmt.Errorf("%s should be a string", name)
+		}
+		switch name {
+		case "resource":
+			resource = value.AsString()
+		case "reason":
+			reason = value.AsString()
+		case "until":
+			until, err = time.Parse(exceptionDateLayout, value.AsString())
+			if err != nil {
+				return "", "", time.Time{}, fmt.Errorf("until should be a date such as 2026-12-31, got \"%s\"", value.AsString())
+			}
+		default:
+			return "", "", time.Time{}, fmt.Errorf("unknown attribute \"%s\", valid attributes are resource, reason and until", name)
+		}
+	}
+
+	if resource == "" {
+		return "", "", time.Time{}, fmt.Errorf("resource is missing")
+	}
+	return resource, reason, until, nil
+}
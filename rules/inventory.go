@@ -0,0 +1,186 @@
+package rules
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// The formats the inventory can be written in
+const (
+	inventoryFormatJSON = "json"
+	inventoryFormatCSV  = "csv"
+)
+
+// Where a tag in the inventory comes from, besides the default tags of the provider, e.g. default_tags
+const tagSourceResource = "resource"
+
+// InventoryConfig is the configuration of the tag inventory in the plugin block
+type InventoryConfig struct {
+	Path   string `hclext:"path"`
+	Format string `hclext:"format,optional"`
+}
+
+// inventoryEntry holds the tags a resource will carry once deployed
+type inventoryEntry struct {
+	Address       string `json:"address"`
+	Type          string `json:"type"`
+	ProviderAlias string `json:"provider_alias"`
+	// Known is false if the tags of the resource could not be evaluated, Tags and Sources are then empty
+	Known bool `json:"known"`
+	// Tags are the tags of the resource merged with the default tags of its provider configuration
+	Tags map[string]string `json:"tags"`
+	// Sources tell for every tag whether it is set by the resource or by the default tags of the provider
+	Sources map[string]string `json:"sources"`
+}
+
+// Returns the format of the inventory, defaulting to the extension of the path
+func (c *InventoryConfig) format() (string, error) {
+	format := c.Format
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(c.Path), ".")
+	}
+
+	switch format {
+	case inventoryFormatJSON, inventoryFormatCSV:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown inventory format \"%s\", valid formats are \"%s\" and \"%s\"", format, inventoryFormatJSON, inventoryFormatCSV)
+}
+
+// Writes the effective tags of every taggable resource of the modules checked so far to the inventory, keeping the entries
+// of every module by module path. TFLint checks the modules the configuration calls one by one before the root module,
+// so the inventory is complete once the root module is checked.
+func writeInventory(runner tflint.Runner, config *InventoryConfig, checked map[string][]*inventoryEntry) error {
+	format, err := config.format()
+	if err != nil {
+		return err
+	}
+
+	modulePath, err := runner.GetModulePath()
+	if err != nil {
+		return err
+	}
+	checked[modulePath.String()], err = getInventoryEntries(runner)
+	if err != nil {
+		return err
+	}
+
+	entries := []*inventoryEntry{}
+	for _, module := range utils.SortedKeys(checked) {
+		entries = append(entries, checked[module]...)
+	}
+
+	path := config.Path
+	if !filepath.IsAbs(path) {
+		wd, err := runner.GetOriginalwd()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(wd, path)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case inventoryFormatCSV:
+		err = writeInventoryCSV(file, entries)
+	default:
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(entries)
+	}
+	// The file is closed in any case, the error of writing it takes precedence over the one of closing it
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Returns the inventory entries of the taggable resources of a single module
+func getInventoryEntries(runner tflint.Runner) ([]*inventoryEntry, error) {
+	prefix, err := modulePrefix(runner)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*inventoryEntry{}
+	for _, provider := range tagging.Providers {
+		defaultTags, err := getDefaultTags(runner, provider)
+		if err != nil {
+			return nil, err
+		}
+
+		resources, err := tagging.GetResources(runner, provider, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range resources {
+			entry := &inventoryEntry{
+				Address:       prefix + resource.Address(),
+				Type:          resource.Type,
+				ProviderAlias: resource.ProviderAlias,
+				Known:         resource.Known(),
+				Tags:          map[string]string{},
+				Sources:       map[string]string{},
+			}
+			if entry.Known {
+				for key, value := range inheritedTags(defaultTags, resource) {
+					entry.Tags[key] = value
+					entry.Sources[key] = defaultTagsName(provider)
+				}
+				for key, value := range resource.Values() {
+					entry.Tags[key] = value
+					entry.Sources[key] = tagSourceResource
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// Writes the inventory with a row for every tag of every resource. Resources without tags get a single row without a tag,
+// resources whose tags could not be evaluated a single row with the source "unknown".
+func writeInventoryCSV(file *os.File, entries []*inventoryEntry) error {
+	writer := csv.NewWriter(file)
+	err := writer.Write([]string{"address", "type", "provider_alias", "tag", "value", "source"})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		resource := []string{entry.Address, entry.Type, entry.ProviderAlias}
+		switch {
+		case !entry.Known:
+			err = writer.Write(append(resource, "", "", "unknown"))
+		case len(entry.Tags) == 0:
+			err = writer.Write(append(resource, "", "", ""))
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, key := range utils.SortedKeys(entry.Tags) {
+			err := writer.Write(append(resource, key, entry.Tags[key], entry.Sources[key]))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
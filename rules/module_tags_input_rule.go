@@ -0,0 +1,265 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/project"
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// ModuleTagsInputRule definition
+type ModuleTagsInputRule struct {
+	tflint.DefaultRule
+	utils.Violations
+}
+
+// ModuleTagsInputRuleConfig is a config of ModuleTagsInputRule
+type ModuleTagsInputRuleConfig struct {
+	Variables []string `hclext:"variables,optional"`
+	Exclude   []string `hclext:"exclude,optional"`
+}
+
+// NewModuleTagsInputRule returns a new rule
+func NewModuleTagsInputRule() *ModuleTagsInputRule {
+	return &ModuleTagsInputRule{}
+}
+
+// Returns a copy of the rule with the violation of the issue it emits attached
+func (r *ModuleTagsInputRule) withViolation(kind string, tags ...string) tflint.Rule {
+	return &ModuleTagsInputRule{Violations: utils.NewViolations(kind, tags...)}
+}
+
+// Name returns the rule name
+func (r *ModuleTagsInputRule) Name() string {
+	return "module_tags_input"
+}
+
+// Enabled returns whether the rule is enabled by default
+func (r *ModuleTagsInputRule) Enabled() bool {
+	return false
+}
+
+// Severity returns the rule severity
+func (r *ModuleTagsInputRule) Severity() tflint.Severity {
+	return tflint.ERROR
+}
+
+// Link returns the rule reference link
+func (r *ModuleTagsInputRule) Link() string {
+	return project.ReferenceLink(r.Name())
+}
+
+// Checks the rule
+func (r *ModuleTagsInputRule) Check(runner tflint.Runner) error {
+	config := &ModuleTagsInputRuleConfig{}
+	if err := runner.DecodeRuleConfig(r.Name(), config); err != nil {
+		return err
+	}
+	if len(config.Variables) == 0 {
+		config.Variables = []string{"tags"}
+	}
+
+	return r.checkModule(runner, config)
+}
+
+// Checks that the module declares one of the tags variables and forwards it to every taggable resource
+func (r *ModuleTagsInputRule) checkModule(runner tflint.Runner, config *ModuleTagsInputRuleConfig) error {
+	content, err := runner.GetModuleContent(&hclext.BodySchema{
+		Blocks: []hclext.BlockSchema{
+			{
+				Type:       "variable",
+				LabelNames: []string{"name"},
+				Body:       &hclext.BodySchema{Attributes: []hclext.AttributeSchema{{Name: "type"}}},
+			},
+		},
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	// The first configured variable the module declares is the one it has to forward
+	input := ""
+	var variable *hclext.Block
+	for _, name := range config.Variables {
+		root, _, _ := strings.Cut(name, ".")
+		index := slices.IndexFunc(content.Blocks, func(block *hclext.Block) bool { return block.Labels[0] == root })
+		if index != -1 {
+			input = name
+			variable = content.Blocks[index]
+			break
+		}
+	}
+
+	if variable == nil {
+		issueRange, err := moduleStart(runner)
+		if err != nil {
+			return err
+		}
+		return runner.EmitIssue(
+			r.withViolation("missing_tags_variable"),
+			fmt.Sprintf("The module does not declare a variable to accept tags from its callers (expected one of %s)", strings.Join(variableReferences(config.Variables), ", ")),
+			issueRange,
+		)
+	}
+
+	if err := r.verifyVariableType(runner, input, variable); err != nil {
+		return err
+	}
+
+	return r.verifyResources(runner, config, input)
+}
+
+// Verifies that the tags variable is declared as a map of strings
+func (r *ModuleTagsInputRule) verifyVariableType(runner tflint.Runner, input string, variable *hclext.Block) error {
+	reference := "var." + input
+
+	attribute, exists := variable.Body.Attributes["type"]
+	if !exists {
+		return runner.EmitIssue(r.withViolation("untyped_tags_variable"), fmt.Sprintf("var.%s should declare its type, so %s is known to be a map of strings", variable.Labels[0], reference), variable.DefRange)
+	}
+
+	ty, _, diags := typeexpr.TypeConstraintWithDefaults(attribute.Expr)
+	if diags.HasErrors() {
+		return nil
+	}
+
+	// Follow the attributes of the input down to the tags, e.g. tags of var.context.tags
+	_, path, _ := strings.Cut(input, ".")
+	for _, name := range strings.Split(path, ".") {
+		if name == "" {
+			break
+		}
+		if !ty.IsObjectType() || !ty.HasAttribute(name) {
+			return runner.EmitIssue(r.withViolation("missing_tags_attribute"), fmt.Sprintf("%s does not exist, the type of var.%s has no attribute \"%s\"", reference, variable.Labels[0], name), attribute.Range)
+		}
+		ty = ty.AttributeType(name)
+	}
+
+	if !ty.Equals(cty.Map(cty.String)) {
+		return runner.EmitIssue(r.withViolation("tags_variable_type"), fmt.Sprintf("%s should be a map of strings, but its type is %s", reference, typeexpr.TypeString(ty)), attribute.Range)
+	}
+	return nil
+}
+
+// Verifies that the taggable resources set their tags from the tags variable
+func (r *ModuleTagsInputRule) verifyResources(runner tflint.Runner, config *ModuleTagsInputRuleConfig, input string) error {
+	// The expressions are read as written, so the instances of resources using count or for_each are checked once
+	resources, err := tagging.GetResourceBlocks(runner, tagging.AWS, config.Exclude, tflint.ExpandModeNone)
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range resources {
+		// Resources whose tags are all standalone, such as autoscaling groups, set their own tags there
+		all := tagging.AWS.ResourceTags(resource.Labels[0])
+		locations := []tagging.Location{}
+		for _, location := range all {
+			if !location.Standalone {
+				locations = append(locations, location)
+			}
+		}
+		if len(locations) == 0 {
+			locations = all
+		}
+
+		exprs := tagExpressions(resource, locations)
+		if len(exprs) == 0 {
+			err := runner.EmitIssue(r.withViolation("untagged_resource"), fmt.Sprintf("The resource has no tags, it should set them from var.%s", input), resource.DefRange)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !slices.ContainsFunc(exprs, func(expr hcl.Expression) bool { return referencesVariable(expr, input) }) {
+			err := runner.EmitIssue(r.withViolation("tags_not_forwarded"), fmt.Sprintf("The resource does not forward var.%s in its tags", input), exprs[0].Range())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the expressions that set the tags of the resource at the given locations.
+// Tags written as blocks are set through the for_each of dynamic blocks.
+func tagExpressions(resource *hclext.Block, locations []tagging.Location) []hcl.Expression {
+	exprs := []hcl.Expression{}
+	for _, location := range locations {
+		switch location.Format {
+		case tagging.BlockFormat:
+			for _, block := range resource.Body.Blocks.OfType("dynamic") {
+				if attribute, exists := block.Body.Attributes["for_each"]; exists && block.Labels[0] == location.Name {
+					exprs = append(exprs, attribute.Expr)
+				}
+			}
+		default:
+			if attribute, exists := resource.Body.Attributes[location.Name]; exists {
+				exprs = append(exprs, attribute.Expr)
+			}
+		}
+	}
+	return exprs
+}
+
+// Returns whether the expression refers to the variable input or to one of its attributes, e.g. var.context.tags for context.tags
+func referencesVariable(expr hcl.Expression, input string) bool {
+	want := append([]string{"var"}, strings.Split(input, ".")...)
+
+	for _, traversal := range expr.Variables() {
+		names := []string{traversal.RootName()}
+		for _, step := range traversal[1:] {
+			switch step := step.(type) {
+			case hcl.TraverseAttr:
+				names = append(names, step.Name)
+			case hcl.TraverseIndex:
+				if step.Key.Type() == cty.String {
+					names = append(names, step.Key.AsString())
+				}
+			}
+			if len(names) == len(want) {
+				break
+			}
+		}
+		if slices.Equal(names, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the variable references for the configured variable names
+func variableReferences(names []string) []string {
+	references := []string{}
+	for _, name := range names {
+		references = append(references, "var."+name)
+	}
+	return references
+}
+
+// Returns the start of the first file of the module, for issues about the module as a whole
+func moduleStart(runner tflint.Runner) (hcl.Range, error) {
+	files, err := runner.GetFiles()
+	if err != nil {
+		return hcl.Range{}, err
+	}
+	filenames := maps.Keys(files)
+	sort.Strings(filenames)
+	if len(filenames) == 0 {
+		return hcl.Range{}, nil
+	}
+
+	start := hcl.Pos{Line: 1, Column: 1}
+	return hcl.Range{Filename: filenames[0], Start: start, End: start}, nil
+}
@@ -0,0 +1,212 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+	"github.com/terraform-linters/tflint-plugin-sdk/terraform/addrs"
+)
+
+func Test_ModuleTagsInputRule(t *testing.T) {
+	tests := []struct {
+		Name string
+		// Path is the path of the module the content is in, the content is in the root module without one
+		Path     addrs.Module
+		Content  string
+		Config   string
+		Expected helper.Issues
+	}{
+		{
+			Name: "Fails_ForRootModule_WithoutTagsVariable",
+			Content: `
+			resource "aws_s3_bucket" "bucket" {
+				bucket = "logs"
+			}`,
+			Config: `
+			rule "module_tags_input" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewModuleTagsInputRule(),
+					Message: "The module does not declare a variable to accept tags from its callers (expected one of var.tags)",
+					Range: hcl.Range{
+						Filename: "module.tf",
+						Start:    hcl.Pos{Line: 1, Column: 1},
+						End:      hcl.Pos{Line: 1, Column: 1},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForRootModule_WithTagsVariable_NotForwardedByResource",
+			Content: `
+			variable "tags" {
+				type = map(string)
+			}
+
+			resource "aws_s3_bucket" "forwarded" {
+				bucket = "forwarded"
+				tags   = var.tags
+			}
+
+			resource "aws_s3_bucket" "ignored" {
+				bucket = "ignored"
+				tags = {
+					Name = "ignored"
+				}
+			}`,
+			Config: `
+			rule "module_tags_input" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewModuleTagsInputRule(),
+					Message: "The resource does not forward var.tags in its tags",
+					Range: hcl.Range{
+						Filename: "module.tf",
+						Start:    hcl.Pos{Line: 13, Column: 12},
+						End:      hcl.Pos{Line: 15, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForModule_WithoutTagsVariable",
+			Path: addrs.Module{"network", "subnets"},
+			Content: `
+			variable "team" {
+				type = string
+			}
+
+			resource "aws_subnet" "this" {
+				vpc_id = "vpc-12345678"
+				tags = {
+					team = var.team
+				}
+			}`,
+			Config: `
+			rule "module_tags_input" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewModuleTagsInputRule(),
+					Message: "module.network.module.subnets: The module does not declare a variable to accept tags from its callers (expected one of var.tags)",
+					Range: hcl.Range{
+						Filename: "main.tf",
+						Start:    hcl.Pos{Line: 1, Column: 1},
+						End:      hcl.Pos{Line: 1, Column: 17},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForModule_WithResourcesNotForwardingTags",
+			Path: addrs.Module{"storage"},
+			Content: `
+			variable "tags" {
+				type = list(string)
+			}
+
+			resource "aws_s3_bucket" "forwarded" {
+				bucket = "forwarded"
+				tags = merge(var.tags, {
+					Name = "forwarded"
+				})
+			}
+
+			resource "aws_s3_bucket" "ignored" {
+				bucket = "ignored"
+				tags = {
+					Name = "ignored"
+				}
+			}
+
+			resource "aws_sqs_queue" "untagged" {
+				name = "untagged"
+			}`,
+			Config: `
+			rule "module_tags_input" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewModuleTagsInputRule(),
+					Message: "module.storage: var.tags should be a map of strings, but its type is list(string)",
+					Range: hcl.Range{
+						Filename: "main.tf",
+						Start:    hcl.Pos{Line: 1, Column: 1},
+						End:      hcl.Pos{Line: 1, Column: 17},
+					},
+				},
+				{
+					Rule:    NewModuleTagsInputRule(),
+					Message: "module.storage.aws_s3_bucket.ignored: The resource does not forward var.tags in its tags",
+					Range: hcl.Range{
+						Filename: "main.tf",
+						Start:    hcl.Pos{Line: 1, Column: 1},
+						End:      hcl.Pos{Line: 1, Column: 17},
+					},
+				},
+				{
+					Rule:    NewModuleTagsInputRule(),
+					Message: "module.storage.aws_sqs_queue.untagged: The resource has no tags, it should set them from var.tags",
+					Range: hcl.Range{
+						Filename: "main.tf",
+						Start:    hcl.Pos{Line: 1, Column: 1},
+						End:      hcl.Pos{Line: 1, Column: 17},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForModule_ForwardingConfiguredVariableAttribute",
+			Path: addrs.Module{"bucket"},
+			Content: `
+			variable "context" {
+				type = object({
+					name = string
+					tags = optional(map(string), {})
+				})
+			}
+
+			resource "aws_s3_bucket" "this" {
+				bucket = var.context.name
+				tags   = var.context["tags"]
+			}`,
+			Config: `
+			rule "module_tags_input" {
+				enabled   = true
+				variables = ["tags", "context.tags"]
+			}`,
+			Expected: helper.Issues{},
+		},
+	}
+
+	rule := NewModuleTagsInputRule()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			files := map[string]string{"module.tf": test.Content, ".tflint.hcl": test.Config}
+			if test.Path != nil {
+				// The root module declares the variable, so only the issues of the called module are reported
+				root := rootModule(test.Path[0])
+				root["variables.tf"] = `variable "tags" {
+  type = map(string)
+}`
+				helper.AssertIssues(t, test.Expected, checkCalledModule(t, rule, test.Path, root, files))
+				return
+			}
+
+			runner := helper.TestRunner(t, files)
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+
+			helper.AssertIssues(t, test.Expected, runner.Issues)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package rules
+
+import (
+	"github.com/0north/tflint-ruleset-0north-plugin/cache"
+	"github.com/0north/tflint-ruleset-0north-plugin/modules"
+	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// RuleSet is the ruleset of the plugin. The rules check every module with a runner caching what they have in common,
+// such as the provider configurations and the tags of the resources, so they are only fetched and evaluated once.
+type RuleSet struct {
+	tflint.BuiltinRuleSet
+
+	config *Config
+
+	// TFLint checks every module of the configuration with a separate call of Check, the modules it calls before the
+	// root module. The baseline, the inventory and the issues of the called modules are kept across the calls until
+	// the root module is checked.
+	baseline  *baseline
+	inventory map[string][]*inventoryEntry
+	issues    *modules.Issues
+}
+
+// Config is the configuration of the plugin block
+type Config struct {
+	// Inventory writes the tags every resource will carry to a file
+	Inventory *InventoryConfig `hclext:"inventory,block"`
+	// Baseline suppresses the violations recorded in a file
+	Baseline *BaselineConfig `hclext:"baseline,block"`
+}
+
+// ConfigSchema returns the schema of the plugin block
+func (r *RuleSet) ConfigSchema() *hclext.BodySchema {
+	return hclext.ImpliedBodySchema(&Config{})
+}
+
+// ApplyConfig reads the plugin block
+func (r *RuleSet) ApplyConfig(content *hclext.BodyContent) error {
+	config := &Config{}
+	if diags := hclext.DecodeBody(content, nil, config); diags.HasErrors() {
+		return diags
+	}
+	r.config = config
+	return nil
+}
+
+// Check runs the enabled rules on the module of the runner with a runner shared by all of them, then writes the tag inventory if it is configured.
+// Issues with violations that are exempted by an exception, or with a baseline that are in the baseline, are not reported.
+func (r *RuleSet) Check(runner tflint.Runner) error {
+	if r.config == nil {
+		r.config = &Config{}
+	}
+
+	path, err := runner.GetModulePath()
+	if err != nil {
+		return err
+	}
+	if path.IsRoot() {
+		defer r.reset()
+	}
+
+	if r.issues == nil {
+		r.issues = &modules.Issues{}
+	}
+	checked, err := modules.NewRunner(runner, r.issues)
+	if err != nil {
+		return err
+	}
+	if r.config.Baseline != nil {
+		if r.baseline == nil {
+			r.baseline, err = loadBaseline(runner, r.config.Baseline)
+			if err != nil {
+				return err
+			}
+		}
+		checked = &baselineRunner{Runner: checked, baseline: r.baseline}
+	}
+
+	exceptions := &exceptionRunner{Runner: checked, baseline: r.baseline}
+
+	// The exceptions are collected with the runner of the rules, so they share what it fetched
+	cached := &rulesRunner{Runner: cache.NewRunner(exceptions), host: runner}
+	collected, err := collectExceptions(cached, r.Rules, r.EnabledRules)
+	if err != nil {
+		return err
+	}
+	exceptions.exceptions = collected
+
+	if err := r.BuiltinRuleSet.Check(cached); err != nil {
+		return err
+	}
+
+	if r.baseline != nil {
+		rules := []string{}
+		for _, rule := range r.EnabledRules {
+			rules = append(rules, rule.Name())
+		}
+		if err := r.baseline.finish(runner, rules); err != nil {
+			return err
+		}
+	}
+
+	// The issues of the called modules are reported on their module calls in the root module
+	if path.IsRoot() {
+		if err := r.issues.Emit(runner); err != nil {
+			return err
+		}
+	}
+
+	if r.config.Inventory != nil {
+		if r.inventory == nil {
+			r.inventory = map[string][]*inventoryEntry{}
+		}
+		return writeInventory(cached, r.config.Inventory, r.inventory)
+	}
+	return nil
+}
+
+// rulesRunner is the runner the rules check a module with, which also reads rule configs with their ranges from TFLint
+type rulesRunner struct {
+	*cache.Runner
+	host tflint.Runner
+}
+
+// GetRuleConfigContent returns the content of the config of the rule with the schema, or nil if TFLint can't return it
+func (r *rulesRunner) GetRuleConfigContent(name string, schema *hclext.BodySchema) (*hclext.BodyContent, error) {
+	return getRuleConfigContent(r.host, name, schema)
+}
+
+// Forgets the baseline, the inventory and the issues of the run once the root module is checked
+func (r *RuleSet) reset() {
+	r.baseline = nil
+	r.inventory = nil
+	r.issues = nil
+}
+
+// rulesetRule reports issues of the ruleset itself, such as stale baseline entries
+type rulesetRule struct {
+	tflint.DefaultRule
+	name     string
+	severity tflint.Severity
+}
+
+// Name returns the rule name
+func (r *rulesetRule) Name() string {
+	return r.name
+}
+
+// Enabled returns whether the rule is enabled by default
+func (r *rulesetRule) Enabled() bool {
+	return true
+}
+
+// Severity returns the rule severity
+func (r *rulesetRule) Severity() tflint.Severity {
+	return r.severity
+}
+
+// Link returns the rule reference link
+func (r *rulesetRule) Link() string {
+	return ""
+}
+
+// Check does nothing, the issues are reported by the ruleset
+func (r *rulesetRule) Check(runner tflint.Runner) error {
+	return nil
+}
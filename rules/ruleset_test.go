@@ -0,0 +1,1111 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+	"github.com/terraform-linters/tflint-plugin-sdk/terraform/addrs"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+func Test_RuleSet(t *testing.T) {
+	files := map[string]string{
+		"resource.tf": `
+		provider "aws" {
+			region = "eu-west-1"
+		}
+
+		resource "aws_s3_bucket" "b" {
+			for_each = {
+				logs = "finance"
+				data = "platform-engineering"
+			}
+			tags = {
+				team = each.value
+			}
+		}
+
+		resource "aws_instance" "web" {
+			tags = {
+				Team = "platform-engineering"
+				team = "platform-engineering"
+			}
+		}
+
+		module "network" {
+			source = "./modules/network"
+		}`,
+		".tflint.hcl": `
+		rule "ensure_default_tags" {
+			enabled = true
+			tags    = ["team", "env"]
+		}
+
+		rule "validate_tags" {
+			enabled = true
+			tags    = [
+				{
+					tag            = "team",
+					allowed_values = ["platform-engineering", "voyage-optimization"]
+				}
+			]
+		}`,
+	}
+	rules := []tflint.Rule{
+		NewEnsureDefaultTagsRule(),
+		NewValidateTagsRule(),
+		NewTagCountLimitRule(),
+		NewDefaultTagsDuplicatesRule(),
+		NewTagKeyCaseCollisionRule(),
+		NewApplyTimeTagValuesRule(),
+		NewModuleTagsInputRule(),
+	}
+
+	// Every rule on its own
+	uncached := &countingRunner{Runner: helper.TestRunner(t, files)}
+	for _, rule := range rules {
+		if err := rule.Check(uncached); err != nil {
+			t.Fatalf("Unexpected error occurred: %s", err)
+		}
+	}
+
+	// The rules sharing the cache of the ruleset
+	cached := &countingRunner{Runner: helper.TestRunner(t, files)}
+	ruleset := &RuleSet{BuiltinRuleSet: tflint.BuiltinRuleSet{Rules: rules, EnabledRules: rules}}
+	if err := ruleset.Check(cached); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+
+	if len(uncached.Issues) == 0 {
+		t.Fatal("Expected issues to compare the cached run with")
+	}
+	helper.AssertIssues(t, uncached.Issues, cached.Issues)
+	if cached.queries >= uncached.queries {
+		t.Errorf("Expected fewer queries with the cache, got %d with and %d without", cached.queries, uncached.queries)
+	}
+}
+
+func Test_RuleSet_Inventory(t *testing.T) {
+	files := map[string]string{
+		"resource.tf": `
+		provider "aws" {
+			region = "eu-west-1"
+			default_tags {
+				tags = {
+					team = "platform-engineering"
+					env  = "prod"
+				}
+			}
+		}
+
+		resource "aws_s3_bucket" "b" {
+			count = 2
+			tags = {
+				env = "dev"
+			}
+		}
+
+		resource "aws_s3_bucket" "other" {
+			tags = aws_s3_bucket.b[0].tags
+		}`,
+	}
+
+	cases := []struct {
+		Name     string
+		Path     string
+		Format   string
+		Expected string
+	}{
+		{
+			Name: "json",
+			Path: "inventory.json",
+			Expected: `[
+  {
+    "address": "aws_s3_bucket.b[0]",
+    "type": "aws_s3_bucket",
+    "provider_alias": "",
+    "known": true,
+    "tags": {
+      "env": "dev",
+      "team": "platform-engineering"
+    },
+    "sources": {
+      "env": "resource",
+      "team": "default_tags"
+    }
+  },
+  {
+    "address": "aws_s3_bucket.b[1]",
+    "type": "aws_s3_bucket",
+    "provider_alias": "",
+    "known": true,
+    "tags": {
+      "env": "dev",
+      "team": "platform-engineering"
+    },
+    "sources": {
+      "env": "resource",
+      "team": "default_tags"
+    }
+  },
+  {
+    "address": "aws_s3_bucket.other",
+    "type": "aws_s3_bucket",
+    "provider_alias": "",
+    "known": false,
+    "tags": {},
+    "sources": {}
+  }
+]
+`,
+		},
+		{
+			Name:   "csv",
+			Path:   "inventory.txt",
+			Format: "csv",
+			Expected: `address,type,provider_alias,tag,value,source
+aws_s3_bucket.b[0],aws_s3_bucket,,env,dev,resource
+aws_s3_bucket.b[0],aws_s3_bucket,,team,platform-engineering,default_tags
+aws_s3_bucket.b[1],aws_s3_bucket,,env,dev,resource
+aws_s3_bucket.b[1],aws_s3_bucket,,team,platform-engineering,default_tags
+aws_s3_bucket.other,aws_s3_bucket,,,,unknown
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.Path)
+			format := ""
+			if tc.Format != "" {
+				format = fmt.Sprintf("format = %q", tc.Format)
+			}
+
+			ruleset := &RuleSet{}
+			applyPluginConfig(t, ruleset, fmt.Sprintf(`
+			inventory {
+				path = %q
+				%s
+			}`, path, format))
+
+			if err := ruleset.Check(newTestRunner(t, files)); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+			if string(content) != tc.Expected {
+				t.Errorf("Expected inventory:\n%s\ngot:\n%s", tc.Expected, content)
+			}
+		})
+	}
+}
+
+func Test_RuleSet_InventoryOfCalledModules(t *testing.T) {
+	root := map[string]string{
+		"main.tf": `
+		provider "aws" {
+			region = "eu-west-1"
+			default_tags {
+				tags = {
+					team = "platform-engineering"
+				}
+			}
+		}
+
+		provider "aws" {
+			alias  = "untagged"
+			region = "eu-west-1"
+		}
+
+		resource "aws_s3_bucket" "b" {
+			tags = {
+				env = "prod"
+			}
+		}
+
+		module "network" {
+			source = "./modules/network"
+		}
+
+		module "untagged" {
+			source = "./modules/network"
+			providers = {
+				aws = aws.untagged
+			}
+		}`,
+	}
+	network := map[string]string{
+		"network.tf": `
+		resource "aws_vpc" "main" {
+			tags = {
+				Name = "main"
+			}
+		}`,
+	}
+
+	path := filepath.Join(t.TempDir(), "inventory.csv")
+	ruleset := &RuleSet{}
+	applyPluginConfig(t, ruleset, fmt.Sprintf(`
+	inventory {
+		path = %q
+	}`, path))
+
+	// TFLint checks the called modules before the root module
+	for _, module := range []addrs.Module{{"network"}, {"untagged"}} {
+		runner := newCalledModuleRunner(t, module, root, network)
+		if err := ruleset.Check(runner); err != nil {
+			t.Fatalf("Unexpected error occurred: %s", err)
+		}
+	}
+	if err := ruleset.Check(helper.TestRunner(t, root)); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	expected := `address,type,provider_alias,tag,value,source
+aws_s3_bucket.b,aws_s3_bucket,,env,prod,resource
+aws_s3_bucket.b,aws_s3_bucket,,team,platform-engineering,default_tags
+module.network.aws_vpc.main,aws_vpc,,Name,main,resource
+module.network.aws_vpc.main,aws_vpc,,team,platform-engineering,default_tags
+module.untagged.aws_vpc.main,aws_vpc,,Name,main,resource
+`
+	if string(content) != expected {
+		t.Errorf("Expected inventory:\n%s\ngot:\n%s", expected, content)
+	}
+}
+
+func Test_RuleSet_InventoryFormat(t *testing.T) {
+	ruleset := &RuleSet{}
+	applyPluginConfig(t, ruleset, `
+	inventory {
+		path = "inventory.yaml"
+	}`)
+
+	err := ruleset.Check(helper.TestRunner(t, map[string]string{}))
+	if err == nil || !strings.Contains(err.Error(), "unknown inventory format \"yaml\"") {
+		t.Errorf("Expected an unknown format error, got %v", err)
+	}
+}
+
+func Test_RuleSet_Baseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	config := fmt.Sprintf(`
+	baseline {
+		path = %q
+	}`, path)
+	tflintConfig := `
+	rule "ensure_default_tags" {
+		enabled = true
+		tags    = ["team", "env"]
+	}`
+	rules := []tflint.Rule{NewEnsureDefaultTagsRule()}
+
+	// Record the existing violations
+	t.Setenv(baselineUpdateEnv, "1")
+	ruleset := &RuleSet{BuiltinRuleSet: tflint.BuiltinRuleSet{Rules: rules, EnabledRules: rules}}
+	applyPluginConfig(t, ruleset, config)
+	runner := newTestRunner(t, map[string]string{
+		"resource.tf": `
+		provider "aws" {
+			region = "eu-west-1"
+		}
+
+		resource "aws_s3_bucket" "logs" {
+			tags = {
+				team = "platform-engineering"
+			}
+		}
+
+		resource "aws_s3_bucket" "b" {
+			count = 2
+			tags = {
+				env = "prod"
+			}
+		}`,
+		".tflint.hcl": tflintConfig,
+	})
+	if err := ruleset.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{}, runner.Issues)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	expected := `{
+  "violations": [
+    {
+      "rule": "ensure_default_tags",
+      "address": "aws_s3_bucket.b[0]",
+      "kind": "missing_tags",
+      "tag": "team"
+    },
+    {
+      "rule": "ensure_default_tags",
+      "address": "aws_s3_bucket.b[1]",
+      "kind": "missing_tags",
+      "tag": "team"
+    },
+    {
+      "rule": "ensure_default_tags",
+      "address": "aws_s3_bucket.logs",
+      "kind": "missing_tags",
+      "tag": "env"
+    },
+    {
+      "rule": "ensure_default_tags",
+      "address": "provider.aws",
+      "kind": "missing_default_tags"
+    }
+  ]
+}
+`
+	if string(content) != expected {
+		t.Errorf("Expected baseline:\n%s\ngot:\n%s", expected, content)
+	}
+
+	// Only new violations are reported, moved or fixed ones are not
+	t.Setenv(baselineUpdateEnv, "")
+	ruleset = &RuleSet{BuiltinRuleSet: tflint.BuiltinRuleSet{Rules: rules, EnabledRules: rules}}
+	applyPluginConfig(t, ruleset, config)
+	runner = newTestRunner(t, map[string]string{
+		"resource.tf": `
+		provider "aws" {
+			region = "eu-west-1"
+		}
+
+		resource "aws_s3_bucket" "b" {
+			count = 2
+			tags = {
+				env = "prod"
+			}
+		}
+
+		resource "aws_s3_bucket" "logs" {
+			tags = {}
+		}`,
+		".tflint.hcl": tflintConfig,
+	})
+	if err := ruleset.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    NewEnsureDefaultTagsRule(),
+			Message: "The resource is missing the following tags: \"env\", \"team\".",
+			Range: hcl.Range{
+				Filename: "resource.tf",
+				Start:    hcl.Pos{Line: 14, Column: 11},
+				End:      hcl.Pos{Line: 14, Column: 13},
+			},
+		},
+	}, runner.Issues)
+
+	// Fixed violations are reported so they can be removed from the baseline
+	runner = newTestRunner(t, map[string]string{
+		"resource.tf": `
+		provider "aws" {
+			region = "eu-west-1"
+		}
+
+		resource "aws_s3_bucket" "b" {
+			count = 2
+			tags = {
+				env  = "prod"
+				team = "platform-engineering"
+			}
+		}
+
+		resource "aws_s3_bucket" "logs" {
+			tags = {
+				env  = "prod"
+				team = "platform-engineering"
+			}
+		}`,
+		".tflint.hcl": tflintConfig,
+	})
+	if err := ruleset.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	start := hcl.Pos{Line: 1, Column: 1}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    &rulesetRule{},
+			Message: "The baseline entry ensure_default_tags on aws_s3_bucket.b[0] (missing_tags, \"team\") no longer occurs, remove it or update the baseline",
+			Range:   hcl.Range{Filename: "resource.tf", Start: start, End: start},
+		},
+		{
+			Rule:    &rulesetRule{},
+			Message: "The baseline entry ensure_default_tags on aws_s3_bucket.b[1] (missing_tags, \"team\") no longer occurs, remove it or update the baseline",
+			Range:   hcl.Range{Filename: "resource.tf", Start: start, End: start},
+		},
+		{
+			Rule:    &rulesetRule{},
+			Message: "The baseline entry ensure_default_tags on aws_s3_bucket.logs (missing_tags, \"env\") no longer occurs, remove it or update the baseline",
+			Range:   hcl.Range{Filename: "resource.tf", Start: start, End: start},
+		},
+		{
+			Rule:    &rulesetRule{},
+			Message: "The baseline entry ensure_default_tags on provider.aws (missing_default_tags) no longer occurs, remove it or update the baseline",
+			Range:   hcl.Range{Filename: "resource.tf", Start: start, End: start},
+		},
+	}, runner.Issues)
+}
+
+func Test_RuleSet_BaselineOfRulesWithoutTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	rules := []tflint.Rule{NewApplyTimeTagValuesRule(), NewModuleTagsInputRule()}
+
+	t.Setenv(baselineUpdateEnv, "1")
+	ruleset := &RuleSet{BuiltinRuleSet: tflint.BuiltinRuleSet{Rules: rules, EnabledRules: rules}}
+	applyPluginConfig(t, ruleset, fmt.Sprintf(`
+	baseline {
+		path = %q
+	}`, path))
+	runner := helper.TestRunner(t, map[string]string{
+		"resource.tf": `
+		variable "tags" {
+			type = map(string)
+		}
+
+		resource "aws_s3_bucket" "logs" {
+			tags = {
+				created = timestamp()
+			}
+		}`,
+		".tflint.hcl": `
+		rule "apply_time_tag_values" {
+			enabled = true
+		}
+
+		rule "module_tags_input" {
+			enabled = true
+		}`,
+	})
+	if err := ruleset.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{}, runner.Issues)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	expected := `{
+  "violations": [
+    {
+      "rule": "apply_time_tag_values",
+      "address": "aws_s3_bucket.logs",
+      "kind": "impure_function_reference:timestamp()",
+      "tag": "created"
+    },
+    {
+      "rule": "module_tags_input",
+      "address": "aws_s3_bucket.logs",
+      "kind": "tags_not_forwarded"
+    }
+  ]
+}
+`
+	if string(content) != expected {
+		t.Errorf("Expected baseline:\n%s\ngot:\n%s", expected, content)
+	}
+}
+
+func Test_RuleSet_BaselineOfCalledModules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	baseline := `{
+  "violations": [
+    {
+      "rule": "ensure_default_tags",
+      "address": "module.network.aws_vpc.main",
+      "kind": "missing_tags",
+      "tag": "team"
+    },
+    {
+      "rule": "ensure_default_tags",
+      "address": "module.storage.aws_s3_bucket.logs",
+      "kind": "missing_tags",
+      "tag": "team"
+    }
+  ]
+}
+`
+	if err := os.WriteFile(path, []byte(baseline), 0o644); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	rules := []tflint.Rule{NewEnsureDefaultTagsRule()}
+	ruleset := &RuleSet{BuiltinRuleSet: tflint.BuiltinRuleSet{Rules: rules, EnabledRules: rules}}
+	applyPluginConfig(t, ruleset, fmt.Sprintf(`
+	baseline {
+		path = %q
+	}`, path))
+
+	config := `
+	rule "ensure_default_tags" {
+		enabled = true
+		tags    = ["team"]
+	}`
+	root := map[string]string{
+		"main.tf": `
+		provider "aws" {
+			region = "eu-west-1"
+		}
+
+		module "network" {
+			source = "./modules/network"
+		}`,
+		".tflint.hcl": config,
+	}
+
+	// TFLint checks the called modules before the root module, the stale entries of the module that wasn't checked are kept
+	runner := newCalledModuleRunner(t, addrs.Module{"network"}, root, map[string]string{
+		"network.tf": `
+		resource "aws_vpc" "main" {
+			tags = {
+				team = "platform-engineering"
+			}
+		}`,
+		".tflint.hcl": config,
+	})
+	if err := ruleset.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	rootRunner := helper.TestRunner(t, root)
+	if err := ruleset.Check(rootRunner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+
+	start := hcl.Pos{Line: 1, Column: 1}
+	helper.AssertIssues(t, helper.Issues{
+		{
+			Rule:    &rulesetRule{},
+			Message: "The baseline entry ensure_default_tags on module.network.aws_vpc.main (missing_tags, \"team\") no longer occurs, remove it or update the baseline",
+			Range:   hcl.Range{Filename: "main.tf", Start: start, End: start},
+		},
+	}, rootRunner.Issues)
+}
+
+func Test_RuleSet_Exceptions(t *testing.T) {
+	today := now
+	now = func() time.Time { return time.Date(2026, 6, 15, 12, 0, 0, 0, time.Local) }
+	t.Cleanup(func() { now = today })
+
+	provider := `
+		# 0north:tag-exception rule=ensure_default_tags expires=2026-12-31 reason=Tags are set on every resource
+		provider "aws" {
+			region = "eu-west-1"
+		}
+`
+	cases := []struct {
+		Name     string
+		Content  string
+		Expected helper.Issues
+	}{
+		{
+			Name: "Exempted",
+			Content: provider + `
+		# 0north:tag-exception rule=ensure_default_tags tag=team expires=2026-06-15 reason=Owned by the data platform from Q3
+		resource "aws_s3_bucket" "logs" {
+			tags = {
+				env = "prod"
+			}
+		}
+
+		resource "aws_s3_bucket" "data" {
+			tags = {
+				"0north:tag-exception" = "rule=ensure_default_tags tag=env tag=team expires=2026-07-01 reason=Migrated in Q3"
+			}
+		}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "NotExempted_ForOtherTags",
+			Content: provider + `
+		resource "aws_s3_bucket" "logs" {
+			# 0north:tag-exception rule=ensure_default_tags tag=team expires=2026-12-31 reason=Owned by the data platform from Q3
+			tags = {}
+		}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The resource is missing the following tags: \"env\", \"team\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 9, Column: 11},
+						End:      hcl.Pos{Line: 9, Column: 13},
+					},
+				},
+			},
+		},
+		{
+			Name: "Expired",
+			Content: provider + `
+		# 0north:tag-exception rule=ensure_default_tags tag=team expires=2026-06-14 reason=Owned by the data platform from Q3
+		resource "aws_s3_bucket" "logs" {
+			tags = {
+				env = "prod"
+			}
+		}`,
+			Expected: helper.Issues{
+				{
+					Rule:    utils.WithSeverity(NewEnsureDefaultTagsRule(), tflint.ERROR),
+					Message: "The exception for \"team\" on aws_s3_bucket.logs expired on 2026-06-14 (Owned by the data platform from Q3)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 7, Column: 3},
+						End:      hcl.Pos{Line: 8, Column: 1},
+					},
+				},
+				{
+					Rule:    NewEnsureDefaultTagsRule(),
+					Message: "The resource is missing the following tags: \"team\".",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 9, Column: 11},
+						End:      hcl.Pos{Line: 11, Column: 5},
+					},
+				},
+			},
+		},
+		{
+			Name: "Invalid",
+			Content: provider + `
+		# 0north:tag-exception rule=ensure_default_tags tag=team expires=2026-12-31
+		resource "aws_s3_bucket" "logs" {
+			tags = {
+				env  = "prod"
+				team = "platform-engineering"
+			}
+		}`,
+			Expected: helper.Issues{
+				{
+					Rule:    &rulesetRule{},
+					Message: "The exception is invalid, reason is missing",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 7, Column: 3},
+						End:      hcl.Pos{Line: 8, Column: 1},
+					},
+				},
+			},
+		},
+	}
+
+	rules := []tflint.Rule{NewEnsureDefaultTagsRule()}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{
+				"resource.tf": tc.Content,
+				".tflint.hcl": `
+				rule "ensure_default_tags" {
+					enabled = true
+					tags    = ["team", "env"]
+				}`,
+			})
+			ruleset := &RuleSet{BuiltinRuleSet: tflint.BuiltinRuleSet{Rules: rules, EnabledRules: rules}}
+			if err := ruleset.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+			helper.AssertIssues(t, tc.Expected, runner.Issues)
+		})
+	}
+}
+
+func Test_RuleSet_ExceptionTag(t *testing.T) {
+	today := now
+	now = func() time.Time { return time.Date(2026, 6, 15, 12, 0, 0, 0, time.Local) }
+	t.Cleanup(func() { now = today })
+
+	// The exception tag is not a tag of the resource, so its value is not held to the tag constraints
+	rules := []tflint.Rule{NewEnsureDefaultTagsRule(), NewValidateTagsRule()}
+	runner := helper.TestRunner(t, map[string]string{
+		"resource.tf": `
+		# 0north:tag-exception rule=ensure_default_tags expires=2026-12-31 reason=Tags are set on every resource
+		provider "aws" {
+			region = "eu-west-1"
+		}
+
+		resource "aws_s3_bucket" "logs" {
+			tags = {
+				"0north:tag-exception" = "rule=ensure_default_tags tag=team expires=2026-12-31 reason=Owned by the data platform, from Q3"
+			}
+		}`,
+		".tflint.hcl": `
+		rule "ensure_default_tags" {
+			enabled = true
+			tags    = ["team"]
+		}
+
+		rule "validate_tags" {
+			enabled = true
+			tags    = []
+		}`,
+	})
+	ruleset := &RuleSet{BuiltinRuleSet: tflint.BuiltinRuleSet{Rules: rules, EnabledRules: rules}}
+	if err := ruleset.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{}, runner.Issues)
+}
+
+func Test_RuleSet_ExceptionOfBaselineEntry(t *testing.T) {
+	today := now
+	now = func() time.Time { return time.Date(2026, 6, 15, 12, 0, 0, 0, time.Local) }
+	t.Cleanup(func() { now = today })
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	baseline := `{
+  "violations": [
+    {
+      "rule": "ensure_default_tags",
+      "address": "aws_s3_bucket.logs",
+      "kind": "missing_tags",
+      "tag": "team"
+    }
+  ]
+}
+`
+	if err := os.WriteFile(path, []byte(baseline), 0o644); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	rules := []tflint.Rule{NewEnsureDefaultTagsRule()}
+	files := map[string]string{
+		"resource.tf": `
+		# 0north:tag-exception rule=ensure_default_tags expires=2026-12-31 reason=Tags are set on every resource
+		provider "aws" {
+			region = "eu-west-1"
+		}
+
+		# 0north:tag-exception rule=ensure_default_tags tag=team expires=2026-12-31 reason=Owned by the data platform from Q3
+		resource "aws_s3_bucket" "logs" {
+			tags = {}
+		}`,
+		".tflint.hcl": `
+		rule "ensure_default_tags" {
+			enabled = true
+			tags    = ["team"]
+		}`,
+	}
+
+	// An exempted violation is not a stale baseline entry
+	ruleset := &RuleSet{BuiltinRuleSet: tflint.BuiltinRuleSet{Rules: rules, EnabledRules: rules}}
+	applyPluginConfig(t, ruleset, fmt.Sprintf(`
+	baseline {
+		path = %q
+	}`, path))
+	runner := helper.TestRunner(t, files)
+	if err := ruleset.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	helper.AssertIssues(t, helper.Issues{}, runner.Issues)
+
+	// and is kept when updating the baseline
+	t.Setenv(baselineUpdateEnv, "1")
+	runner = helper.TestRunner(t, files)
+	if err := ruleset.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	if string(content) != baseline {
+		t.Errorf("Expected baseline:\n%s\ngot:\n%s", baseline, content)
+	}
+}
+
+// Applies the body of a plugin block to the ruleset
+func applyPluginConfig(t *testing.T, ruleset *RuleSet, config string) {
+	file, diags := hclsyntax.ParseConfig([]byte(config), ".tflint.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("Unexpected error occurred: %s", diags)
+	}
+	content, diags := hclext.Content(file.Body, ruleset.ConfigSchema())
+	if diags.HasErrors() {
+		t.Fatalf("Unexpected error occurred: %s", diags)
+	}
+	if err := ruleset.ApplyConfig(content); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+}
+
+func Benchmark_RuleSet(b *testing.B) {
+	rules := []tflint.Rule{
+		NewEnsureDefaultTagsRule(),
+		NewValidateTagsRule(),
+		NewTagCountLimitRule(),
+		NewDefaultTagsDuplicatesRule(),
+		NewTagKeyCaseCollisionRule(),
+		NewApplyTimeTagValuesRule(),
+		NewModuleTagsInputRule(),
+	}
+
+	b.Run("Uncached", func(b *testing.B) {
+		runner := newBenchmarkRunner(b, benchmarkResources)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, rule := range rules {
+				if err := rule.Check(runner); err != nil {
+					b.Fatalf("Unexpected error occurred: %s", err)
+				}
+			}
+		}
+		b.ReportMetric(float64(runner.queries)/float64(b.N), "queries/op")
+	})
+
+	b.Run("Cached", func(b *testing.B) {
+		runner := newBenchmarkRunner(b, benchmarkResources)
+		ruleset := &RuleSet{BuiltinRuleSet: tflint.BuiltinRuleSet{Rules: rules, EnabledRules: rules}}
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := ruleset.Check(runner); err != nil {
+				b.Fatalf("Unexpected error occurred: %s", err)
+			}
+		}
+		b.ReportMetric(float64(runner.queries)/float64(b.N), "queries/op")
+	})
+}
+
+// countingRunner counts the queries for module content, each of which is a round trip to TFLint outside of tests
+type countingRunner struct {
+	*helper.Runner
+	queries int
+}
+
+func (r *countingRunner) GetModuleContent(schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (*hclext.BodyContent, error) {
+	r.queries++
+	return r.Runner.GetModuleContent(schema, opts)
+}
+
+func (r *countingRunner) GetResourceContent(name string, schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (*hclext.BodyContent, error) {
+	r.queries++
+	return r.Runner.GetResourceContent(name, schema, opts)
+}
+
+func (r *countingRunner) GetProviderContent(name string, schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (*hclext.BodyContent, error) {
+	r.queries++
+	return r.Runner.GetProviderContent(name, schema, opts)
+}
+
+// testRunner expands resources using count or for_each into a block per instance, the way TFLint does unless asked
+// not to, as a helper runner returns the blocks as written. The attributes referring to each or count are bound to their
+// value for the instance, evaluated with the functions a helper runner lacks. Resources whose count or for_each can't be
+// evaluated are a single instance with unknown each and count. The content of rule configs is read from .tflint.hcl with
+// its ranges, as TFLint returns it.
+type testRunner struct {
+	*helper.Runner
+	files map[string]string
+}
+
+func newTestRunner(t *testing.T, files map[string]string) *testRunner {
+	return &testRunner{Runner: helper.TestRunner(t, files), files: files}
+}
+
+func (r *testRunner) GetRuleConfigContent(name string, schema *hclext.BodySchema) (*hclext.BodyContent, error) {
+	src, exists := r.files[".tflint.hcl"]
+	if !exists {
+		return nil, nil
+	}
+	file, diags := hclsyntax.ParseConfig([]byte(src), ".tflint.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	config, _, diags := file.Body.PartialContent(&hcl.BodySchema{Blocks: []hcl.BlockHeaderSchema{{Type: "rule", LabelNames: []string{"name"}}}})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	for _, rule := range config.Blocks {
+		if rule.Labels[0] == name {
+			content, diags := hclext.PartialContent(rule.Body, schema)
+			if diags.HasErrors() {
+				return nil, diags
+			}
+			return content, nil
+		}
+	}
+	return &hclext.BodyContent{}, nil
+}
+
+func (r *testRunner) GetModuleContent(schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (*hclext.BodyContent, error) {
+	content, err := r.Runner.GetModuleContent(schema, opts)
+	if err != nil || (opts != nil && opts.ExpandMode == tflint.ExpandModeNone) {
+		return content, err
+	}
+
+	meta, err := r.Runner.GetModuleContent(&hclext.BodySchema{
+		Blocks: []hclext.BlockSchema{
+			{
+				Type:       "resource",
+				LabelNames: []string{"type", "name"},
+				Body:       &hclext.BodySchema{Attributes: []hclext.AttributeSchema{{Name: "count"}, {Name: "for_each"}}},
+			},
+		},
+	}, &tflint.GetModuleContentOption{ExpandMode: tflint.ExpandModeNone})
+	if err != nil {
+		return nil, err
+	}
+	var variables cty.Value
+	if err := r.Runner.EvaluateExpr(&hclsyntax.ScopeTraversalExpr{Traversal: hcl.Traversal{hcl.TraverseRoot{Name: "var"}}}, &variables, nil); err != nil {
+		return nil, err
+	}
+
+	expanded := &hclext.BodyContent{Attributes: content.Attributes, Blocks: hclext.Blocks{}}
+	for _, block := range content.Blocks {
+		index := slices.IndexFunc(meta.Blocks, func(resource *hclext.Block) bool { return resource.DefRange == block.DefRange })
+		if block.Type != "resource" || index == -1 {
+			expanded.Blocks = append(expanded.Blocks, block)
+			continue
+		}
+		instances := r.instances(meta.Blocks[index])
+		if instances == nil {
+			expanded.Blocks = append(expanded.Blocks, block)
+			continue
+		}
+		for _, instance := range instances {
+			instance["var"] = variables
+			expanded.Blocks = append(expanded.Blocks, bindBlock(block, &hcl.EvalContext{Variables: instance, Functions: policyFunctions}))
+		}
+	}
+	return expanded, nil
+}
+
+// Returns the each or count object of every instance of the resource, or nil if it uses neither
+func (r *testRunner) instances(resource *hclext.Block) []map[string]cty.Value {
+	instances := []map[string]cty.Value{}
+	if attribute, exists := resource.Body.Attributes["for_each"]; exists {
+		var forEach cty.Value
+		if err := r.Runner.EvaluateExpr(attribute.Expr, &forEach, nil); err != nil {
+			return []map[string]cty.Value{{"each": cty.ObjectVal(map[string]cty.Value{"key": cty.UnknownVal(cty.String), "value": cty.DynamicVal})}}
+		}
+		for iterator := forEach.ElementIterator(); iterator.Next(); {
+			key, value := iterator.Element()
+			instances = append(instances, map[string]cty.Value{"each": cty.ObjectVal(map[string]cty.Value{"key": key, "value": value})})
+		}
+		return instances
+	}
+	if attribute, exists := resource.Body.Attributes["count"]; exists {
+		var count int
+		if err := r.Runner.EvaluateExpr(attribute.Expr, &count, nil); err != nil {
+			return []map[string]cty.Value{{"count": cty.ObjectVal(map[string]cty.Value{"index": cty.UnknownVal(cty.Number)})}}
+		}
+		for index := 0; index < count; index++ {
+			instances = append(instances, map[string]cty.Value{"count": cty.ObjectVal(map[string]cty.Value{"index": cty.NumberIntVal(int64(index))})})
+		}
+		return instances
+	}
+	return nil
+}
+
+// Returns a copy of the block with the attributes referring to each or count bound to their value
+func bindBlock(block *hclext.Block, ctx *hcl.EvalContext) *hclext.Block {
+	body := &hclext.BodyContent{Attributes: hclext.Attributes{}, Blocks: hclext.Blocks{}}
+	for name, attribute := range block.Body.Attributes {
+		bound := *attribute
+		refersToInstance := slices.ContainsFunc(attribute.Expr.Variables(), func(traversal hcl.Traversal) bool {
+			return traversal.RootName() == "each" || traversal.RootName() == "count"
+		})
+		if value, diags := attribute.Expr.Value(ctx); refersToInstance && !diags.HasErrors() {
+			bound.Expr = hclext.BindValue(value, attribute.Expr)
+		}
+		body.Attributes[name] = &bound
+	}
+	for _, nested := range block.Body.Blocks {
+		body.Blocks = append(body.Blocks, bindBlock(nested, ctx))
+	}
+
+	bound := *block
+	bound.Body = body
+	return &bound
+}
+
+// calledModuleRunner checks a module called from the root module, the way TFLint does with --call-module-type=all.
+// A helper runner only has the files of one module, so queries and evaluations in the context of the root module are
+// answered by a runner with the files of the root module. TFLint drops issues of a called module unless they are on
+// an expression derived from a module variable, which the ruleset never emits, so an emitted issue fails the test.
+type calledModuleRunner struct {
+	*testRunner
+	t    *testing.T
+	root *helper.Runner
+	path addrs.Module
+}
+
+// Returns the runner the ruleset checks the module at the path with
+func newCalledModuleRunner(t *testing.T, path addrs.Module, rootFiles map[string]string, files map[string]string) tflint.Runner {
+	return &calledModuleRunner{testRunner: newTestRunner(t, files), t: t, root: helper.TestRunner(t, rootFiles), path: path}
+}
+
+// Checks the module at the path with the rule, then the root module, and returns the issues TFLint reports.
+// The root module is checked with the configuration of the called module.
+func checkCalledModule(t *testing.T, rule tflint.Rule, path addrs.Module, rootFiles map[string]string, files map[string]string) helper.Issues {
+	ruleset := &RuleSet{BuiltinRuleSet: tflint.BuiltinRuleSet{Rules: []tflint.Rule{rule}, EnabledRules: []tflint.Rule{rule}}}
+	if err := ruleset.Check(newCalledModuleRunner(t, path, rootFiles, files)); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+
+	root := maps.Clone(rootFiles)
+	if config, exists := files[".tflint.hcl"]; exists {
+		root[".tflint.hcl"] = config
+	}
+	runner := helper.TestRunner(t, root)
+	if err := ruleset.Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	return runner.Issues
+}
+
+// Returns a root module calling the module with the name
+func rootModule(name string) map[string]string {
+	return map[string]string{"main.tf": fmt.Sprintf("module %q {\n  source = \"./modules/%s\"\n}\n", name, name)}
+}
+
+func (r *calledModuleRunner) EmitIssue(rule tflint.Rule, message string, issueRange hcl.Range) error {
+	r.t.Errorf("Issue of the called module would be dropped by TFLint: %s: %s (%s)", rule.Name(), message, issueRange)
+	return nil
+}
+
+func (r *calledModuleRunner) GetModulePath() (addrs.Module, error) {
+	return r.path, nil
+}
+
+func (r *calledModuleRunner) GetModuleContent(schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (*hclext.BodyContent, error) {
+	if opts != nil && opts.ModuleCtx == tflint.RootModuleCtxType {
+		return r.root.GetModuleContent(schema, opts)
+	}
+	return r.testRunner.GetModuleContent(schema, opts)
+}
+
+func (r *calledModuleRunner) GetProviderContent(name string, schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (*hclext.BodyContent, error) {
+	if opts != nil && opts.ModuleCtx == tflint.RootModuleCtxType {
+		return r.root.GetProviderContent(name, schema, opts)
+	}
+	return r.Runner.GetProviderContent(name, schema, opts)
+}
+
+func (r *calledModuleRunner) EvaluateExpr(expr hcl.Expression, ret interface{}, opts *tflint.EvaluateExprOption) error {
+	if opts != nil && opts.ModuleCtx == tflint.RootModuleCtxType {
+		return r.root.EvaluateExpr(expr, ret, opts)
+	}
+	return r.Runner.EvaluateExpr(expr, ret, opts)
+}
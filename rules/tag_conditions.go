@@ -0,0 +1,74 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+)
+
+// TagConditionConfig is a requirement on a tag that only applies to resources whose tags match a condition,
+// e.g. if "environment" matches "prod" then "data-classification" is required
+type TagConditionConfig struct {
+	Name        string `hclext:"name,label"`
+	IfTag       string `hclext:"if_tag"`
+	IfMatches   string `hclext:"if_matches"`
+	ThenTag     string `hclext:"then_tag"`
+	ThenMatches string `hclext:"then_matches,optional"`
+}
+
+// tagCondition is a condition with its regular expressions compiled. They have to match the whole value.
+type tagCondition struct {
+	TagConditionConfig
+	ifPattern   *regexp.Regexp
+	thenPattern *regexp.Regexp
+}
+
+// Compiles the conditions of a rule config. The then_matches of the conditions is required when checking values and not allowed otherwise.
+func compileTagConditions(rule string, configs []TagConditionConfig, checkValues bool) ([]*tagCondition, error) {
+	conditions := []*tagCondition{}
+	for _, config := range configs {
+		condition := &tagCondition{TagConditionConfig: config}
+
+		ifPattern, err := regexp.Compile("^(?:" + config.IfMatches + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid if_matches in condition \"%s\": %w", config.Name, err)
+		}
+		condition.ifPattern = ifPattern
+
+		switch {
+		case checkValues && config.ThenMatches == "":
+			return nil, fmt.Errorf("then_matches is missing in condition \"%s\"", config.Name)
+		case !checkValues && config.ThenMatches != "":
+			return nil, fmt.Errorf("then_matches in condition \"%s\" is not supported by %s, values are checked by validate_tags", config.Name, rule)
+		case checkValues:
+			thenPattern, err := regexp.Compile("^(?:" + config.ThenMatches + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid then_matches in condition \"%s\": %w", config.Name, err)
+			}
+			condition.thenPattern = thenPattern
+		}
+
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// Returns the keys the tags of the condition are kept under on the resources of the provider
+func (c *tagCondition) keys(provider tagging.Provider, kubernetesLabelKeys map[string]string) (ifKey string, thenKey string) {
+	return providerTagKey(provider, c.IfTag, kubernetesLabelKeys), providerTagKey(provider, c.ThenTag, kubernetesLabelKeys)
+}
+
+// Returns whether the condition applies to the tags, and if so why, e.g. "environment" is "prod"
+func (c *tagCondition) applies(tags map[string]string, ifKey string) (string, bool) {
+	value, exists := tags[ifKey]
+	if !exists || !c.ifPattern.MatchString(value) {
+		return "", false
+	}
+
+	reason := fmt.Sprintf("\"%s\" is \"%s\"", ifKey, value)
+	if c.IfMatches != regexp.QuoteMeta(value) {
+		reason += fmt.Sprintf(", matching \"%s\"", c.IfMatches)
+	}
+	return reason, true
+}
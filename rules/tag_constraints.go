@@ -0,0 +1,334 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+)
+
+// TagConstraintConfig overrides the built-in tag constraints for a set of resource types
+type TagConstraintConfig struct {
+	Name              string   `hclext:"name,label"`
+	Resources         []string `hclext:"resources"`
+	MaxKeyLength      int      `hclext:"max_key_length,optional"`
+	MaxValueLength    int      `hclext:"max_value_length,optional"`
+	AllowedCharacters string   `hclext:"allowed_characters,optional"`
+}
+
+// tagConstraint describes the limits a cloud provider puts on tag keys and values. A maximum length of 0 means no limit.
+type tagConstraint struct {
+	noun                  string
+	maxKeyLength          int
+	maxValueLength        int
+	allowedKeyChars       *regexp.Regexp
+	allowedKeyCharsDesc   string
+	allowedValueChars     *regexp.Regexp
+	allowedValueCharsDesc string
+	keyStart              *regexp.Regexp
+	keyStartDesc          string
+	reservedPrefixes      []string
+	// format checks the structure of keys and values beyond their characters and length
+	format func(noun string, key string, value string) []string
+}
+
+// tagConstraintOverride applies a partial tagConstraint to resource types matching one of the patterns
+type tagConstraintOverride struct {
+	resources  []string
+	constraint tagConstraint
+}
+
+var awsAllowedChars = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]$`)
+
+const awsAllowedCharsDesc = "letters, numbers, spaces and _ . : / = + - @"
+
+// https://docs.aws.amazon.com/tag-editor/latest/userguide/tagging.html#tag-conventions
+var awsDefaultTagConstraint = tagConstraint{
+	maxKeyLength:          128,
+	maxValueLength:        256,
+	allowedKeyChars:       awsAllowedChars,
+	allowedKeyCharsDesc:   awsAllowedCharsDesc,
+	allowedValueChars:     awsAllowedChars,
+	allowedValueCharsDesc: awsAllowedCharsDesc,
+	reservedPrefixes:      []string{"aws:"},
+}
+
+// Built-in service specific overrides of awsDefaultTagConstraint
+var awsTagConstraintOverrides = []tagConstraintOverride{
+	{
+		// EC2 allows any character in its tags
+		// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/Using_Tags.html#tag-restrictions
+		resources: []string{
+			"aws_ami*",
+			"aws_customer_gateway",
+			"aws_default_*",
+			"aws_ebs_*",
+			"aws_ec2_*",
+			"aws_egress_only_internet_gateway",
+			"aws_eip",
+			"aws_instance",
+			"aws_internet_gateway",
+			"aws_key_pair",
+			"aws_launch_template",
+			"aws_nat_gateway",
+			"aws_network_*",
+			"aws_placement_group",
+			"aws_route_table",
+			"aws_security_group",
+			"aws_spot_*",
+			"aws_subnet",
+			"aws_vpc*",
+			"aws_vpn_*",
+		},
+		constraint: tagConstraint{
+			allowedKeyChars:       regexp.MustCompile(`^(?s:.)$`),
+			allowedKeyCharsDesc:   "any character",
+			allowedValueChars:     regexp.MustCompile(`^(?s:.)$`),
+			allowedValueCharsDesc: "any character",
+		},
+	},
+}
+
+var googleAllowedChars = regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{N}_-]$`)
+
+const googleAllowedCharsDesc = "lowercase letters, numbers, underscores and dashes"
+
+// https://cloud.google.com/resource-manager/docs/creating-managing-labels#requirements
+var googleDefaultTagConstraint = tagConstraint{
+	maxKeyLength:          63,
+	maxValueLength:        63,
+	allowedKeyChars:       googleAllowedChars,
+	allowedKeyCharsDesc:   googleAllowedCharsDesc,
+	allowedValueChars:     googleAllowedChars,
+	allowedValueCharsDesc: googleAllowedCharsDesc,
+	keyStart:              regexp.MustCompile(`^[\p{Ll}\p{Lo}]`),
+	keyStartDesc:          "a lowercase letter",
+}
+
+// https://learn.microsoft.com/en-us/azure/azure-resource-manager/management/tag-resources#limitations
+var azurermDefaultTagConstraint = tagConstraint{
+	maxKeyLength:        512,
+	maxValueLength:      256,
+	allowedKeyChars:     regexp.MustCompile(`^[^<>%&\\?/]$`),
+	allowedKeyCharsDesc: "all characters except < > % & \\ ? /",
+}
+
+// Built-in service specific overrides of azurermDefaultTagConstraint
+var azurermTagConstraintOverrides = []tagConstraintOverride{
+	{
+		// Storage accounts only allow keys up to 128 characters
+		resources: []string{"azurerm_storage_account"},
+		constraint: tagConstraint{
+			maxKeyLength: 128,
+		},
+	},
+	{
+		// DNS zones don't support spaces or parentheses in keys
+		resources: []string{"azurerm_dns_zone", "azurerm_private_dns_zone"},
+		constraint: tagConstraint{
+			allowedKeyChars:     regexp.MustCompile(`^[^<>%&\\?/ ()]$`),
+			allowedKeyCharsDesc: "all characters except < > % & \\ ? / ( ) and spaces",
+		},
+	},
+	{
+		// Traffic Manager doesn't support spaces, # or : in keys
+		resources: []string{"azurerm_traffic_manager_profile"},
+		constraint: tagConstraint{
+			allowedKeyChars:     regexp.MustCompile(`^[^<>%&\\?/ #:]$`),
+			allowedKeyCharsDesc: "all characters except < > % & \\ ? / # : and spaces",
+		},
+	},
+	{
+		// Front Door doesn't support # or : in keys
+		resources: []string{"azurerm_cdn_frontdoor_*", "azurerm_frontdoor*"},
+		constraint: tagConstraint{
+			allowedKeyChars:     regexp.MustCompile(`^[^<>%&\\?/#:]$`),
+			allowedKeyCharsDesc: "all characters except < > % & \\ ? / # :",
+		},
+	},
+}
+
+var kubernetesDNSSubdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+var kubernetesQualifiedName = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
+var kubernetesAllowedValueChars = regexp.MustCompile(`^[A-Za-z0-9_.\-]$`)
+
+const kubernetesMaxPrefixLength = 253
+
+const kubernetesMaxNameLength = 63
+
+// https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#syntax-and-character-set
+var kubernetesDefaultTagConstraint = tagConstraint{
+	maxValueLength:        kubernetesMaxNameLength,
+	allowedValueChars:     kubernetesAllowedValueChars,
+	allowedValueCharsDesc: "letters, numbers, underscores, dashes and dots",
+	format:                kubernetesLabelFormat,
+}
+
+// Checks that the label key is an optional DNS-1123 subdomain prefix followed by a name, and that the value starts and ends with a letter or number
+func kubernetesLabelFormat(noun string, key string, value string) []string {
+	violations := []string{}
+
+	name := key
+	if prefix, rest, found := strings.Cut(key, "/"); found {
+		name = rest
+		if len(prefix) > kubernetesMaxPrefixLength || !kubernetesDNSSubdomain.MatchString(prefix) {
+			violations = append(violations, fmt.Sprintf("%s key \"%s\" has an invalid prefix \"%s\", it must be a DNS-1123 subdomain of at most %d lowercase letters, numbers, dashes and dots, starting and ending with a letter or number", noun, key, prefix, kubernetesMaxPrefixLength))
+		}
+	}
+	if len(name) > kubernetesMaxNameLength || !kubernetesQualifiedName.MatchString(name) {
+		violations = append(violations, fmt.Sprintf("%s key \"%s\" has an invalid name \"%s\", it must be at most %d letters, numbers, underscores, dashes and dots, starting and ending with a letter or number", noun, key, name, kubernetesMaxNameLength))
+	}
+
+	// Invalid characters and lengths are already reported on their own
+	if value != "" && len(value) <= kubernetesMaxNameLength && !kubernetesQualifiedName.MatchString(value) {
+		if _, _, found := invalidCharacter(value, kubernetesAllowedValueChars); !found {
+			violations = append(violations, fmt.Sprintf("%s value \"%s\" for %s \"%s\" must start and end with a letter or number", noun, value, strings.ToLower(noun), key))
+		}
+	}
+
+	return violations
+}
+
+// The general tag constraints of each provider
+var defaultTagConstraints = map[string]tagConstraint{
+	"aws":        awsDefaultTagConstraint,
+	"awscc":      awsDefaultTagConstraint,
+	"azurerm":    azurermDefaultTagConstraint,
+	"google":     googleDefaultTagConstraint,
+	"kubernetes": kubernetesDefaultTagConstraint,
+}
+
+// The most permissive of the general tag constraints, for tags that can end up on resources of any provider
+var permissiveTagConstraint = tagConstraint{
+	noun:           "Tag",
+	maxKeyLength:   512,
+	maxValueLength: 256,
+}
+
+// Built-in service specific overrides of the general tag constraints
+var tagConstraintOverrides = map[string][]tagConstraintOverride{
+	"aws":     awsTagConstraintOverrides,
+	"azurerm": azurermTagConstraintOverrides,
+}
+
+// Returns the tag constraint that applies to the given resource type of the provider, taking built-in and configured overrides into account.
+// An empty resource type returns the general constraint of the provider, which is what provider level default tags are held to.
+func resolveTagConstraint(provider tagging.Provider, resourceType string, configs []TagConstraintConfig) (tagConstraint, error) {
+	constraint := defaultTagConstraints[provider.Name()]
+	constraint.noun = provider.Noun()
+	if resourceType == "" {
+		return constraint, nil
+	}
+
+	overrides := append([]tagConstraintOverride{}, tagConstraintOverrides[provider.Name()]...)
+	for _, config := range configs {
+		override := tagConstraintOverride{
+			resources: config.Resources,
+			constraint: tagConstraint{
+				maxKeyLength:   config.MaxKeyLength,
+				maxValueLength: config.MaxValueLength,
+			},
+		}
+		if config.AllowedCharacters != "" {
+			allowedChars, err := regexp.Compile("^[" + config.AllowedCharacters + "]$")
+			if err != nil {
+				return constraint, fmt.Errorf("invalid allowed_characters in constraint \"%s\": %w", config.Name, err)
+			}
+			override.constraint.allowedKeyChars = allowedChars
+			override.constraint.allowedKeyCharsDesc = "[" + config.AllowedCharacters + "]"
+			override.constraint.allowedValueChars = allowedChars
+			override.constraint.allowedValueCharsDesc = "[" + config.AllowedCharacters + "]"
+		}
+		overrides = append(overrides, override)
+	}
+
+	for _, override := range overrides {
+		if !matchesResourceType(resourceType, override.resources) {
+			continue
+		}
+		if override.constraint.maxKeyLength != 0 {
+			constraint.maxKeyLength = override.constraint.maxKeyLength
+		}
+		if override.constraint.maxValueLength != 0 {
+			constraint.maxValueLength = override.constraint.maxValueLength
+		}
+		if override.constraint.allowedKeyChars != nil {
+			constraint.allowedKeyChars = override.constraint.allowedKeyChars
+			constraint.allowedKeyCharsDesc = override.constraint.allowedKeyCharsDesc
+		}
+		if override.constraint.allowedValueChars != nil {
+			constraint.allowedValueChars = override.constraint.allowedValueChars
+			constraint.allowedValueCharsDesc = override.constraint.allowedValueCharsDesc
+		}
+	}
+
+	return constraint, nil
+}
+
+// Checks whether the resource type matches one of the given glob patterns
+func matchesResourceType(resourceType string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, resourceType); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns a description of every way the given tag violates the constraint
+func (c tagConstraint) violations(key string, value string) []string {
+	violations := []string{}
+
+	noun := c.noun
+	lowerNoun := strings.ToLower(noun)
+
+	if key == "" {
+		violations = append(violations, fmt.Sprintf("%s key must not be empty", noun))
+	}
+	if length := utf8.RuneCountInString(key); c.maxKeyLength > 0 && length > c.maxKeyLength {
+		violations = append(violations, fmt.Sprintf("%s key \"%s\" is %d characters long, exceeding the maximum of %d", noun, key, length, c.maxKeyLength))
+	}
+	if length := utf8.RuneCountInString(value); c.maxValueLength > 0 && length > c.maxValueLength {
+		violations = append(violations, fmt.Sprintf("%s value for %s \"%s\" is %d characters long, exceeding the maximum of %d", noun, lowerNoun, key, length, c.maxValueLength))
+	}
+	if c.keyStart != nil && key != "" && !c.keyStart.MatchString(key) {
+		violations = append(violations, fmt.Sprintf("%s key \"%s\" must start with %s", noun, key, c.keyStartDesc))
+	}
+	if char, position, found := invalidCharacter(key, c.allowedKeyChars); found {
+		violations = append(violations, fmt.Sprintf("%s key \"%s\" contains invalid character %q at position %d (allowed are %s)", noun, key, char, position, c.allowedKeyCharsDesc))
+	}
+	if char, position, found := invalidCharacter(value, c.allowedValueChars); found {
+		violations = append(violations, fmt.Sprintf("%s value \"%s\" for %s \"%s\" contains invalid character %q at position %d (allowed are %s)", noun, value, lowerNoun, key, char, position, c.allowedValueCharsDesc))
+	}
+	for _, prefix := range c.reservedPrefixes {
+		if strings.HasPrefix(strings.ToLower(key), prefix) {
+			violations = append(violations, fmt.Sprintf("%s key \"%s\" uses the reserved prefix \"%s\"", noun, key, prefix))
+		}
+	}
+	if c.format != nil && key != "" {
+		violations = append(violations, c.format(noun, key, value)...)
+	}
+
+	return violations
+}
+
+// Finds the first character not matched by allowedChars and returns it with its 1-based position
+func invalidCharacter(s string, allowedChars *regexp.Regexp) (string, int, bool) {
+	if allowedChars == nil {
+		return "", 0, false
+	}
+
+	position := 0
+	for _, char := range s {
+		position++
+		if !allowedChars.MatchString(string(char)) {
+			return string(char), position, true
+		}
+	}
+	return "", 0, false
+}
@@ -0,0 +1,168 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/project"
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// AWS rejects resources with more than 50 user tags
+// https://docs.aws.amazon.com/tag-editor/latest/userguide/tagging.html#tag-conventions
+const awsMaxTagsPerResource = 50
+
+// TagCountLimitRule definition
+type TagCountLimitRule struct {
+	tflint.DefaultRule
+	utils.Violations
+}
+
+// TagCountLimitRuleConfig is a config of TagCountLimitRule
+type TagCountLimitRuleConfig struct {
+	Limit     int      `hclext:"limit,optional"`
+	WarnAt    int      `hclext:"warn_at,optional"`
+	Exclude   []string `hclext:"exclude,optional"`
+	OnUnknown string   `hclext:"on_unknown,optional"`
+}
+
+// NewTagCountLimitRule returns a new rule
+func NewTagCountLimitRule() *TagCountLimitRule {
+	return &TagCountLimitRule{}
+}
+
+// Returns a copy of the rule with the violation of the issue it emits attached
+func (r *TagCountLimitRule) withViolation(kind string, tags ...string) tflint.Rule {
+	return &TagCountLimitRule{Violations: utils.NewViolations(kind, tags...)}
+}
+
+// Name returns the rule name
+func (r *TagCountLimitRule) Name() string {
+	return "tag_count_limit"
+}
+
+// Enabled returns whether the rule is enabled by default
+func (r *TagCountLimitRule) Enabled() bool {
+	return false
+}
+
+// Severity returns the rule severity
+func (r *TagCountLimitRule) Severity() tflint.Severity {
+	return tflint.ERROR
+}
+
+// Link returns the rule reference link
+func (r *TagCountLimitRule) Link() string {
+	return project.ReferenceLink(r.Name())
+}
+
+// Checks the rule
+func (r *TagCountLimitRule) Check(runner tflint.Runner) error {
+	config := &TagCountLimitRuleConfig{}
+	if err := runner.DecodeRuleConfig(r.Name(), config); err != nil {
+		return err
+	}
+	if config.Limit == 0 {
+		config.Limit = awsMaxTagsPerResource
+	}
+	if config.WarnAt == 0 {
+		config.WarnAt = config.Limit * 9 / 10
+	}
+	onUnknown, err := resolveOnUnknown(config.OnUnknown)
+	if err != nil {
+		return err
+	}
+	config.OnUnknown = onUnknown
+
+	// The limit is specific to AWS
+	defaultTags, err := getDefaultTags(runner, tagging.AWS)
+	if err != nil {
+		return err
+	}
+
+	// The tags of resources inheriting default tags that could not be evaluated can't be counted
+	providerConfigs, err := tagging.GetProviderConfigs(runner, tagging.AWS)
+	if err != nil {
+		return err
+	}
+	unknownDefaultTags := map[string]bool{}
+	for _, providerConfig := range providerConfigs {
+		for _, tags := range providerConfig.DefaultTags {
+			if tags.Known() {
+				continue
+			}
+			unknownDefaultTags[providerConfig.Alias] = true
+			err := emitUnknownTags(runner, r, config.OnUnknown, fmt.Sprintf("the %s provider", tagging.AWS.Name()), tags)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	resources, err := tagging.GetResources(runner, tagging.AWS, config.Exclude)
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range resources {
+		if !resource.Known() {
+			for _, tags := range resource.OwnTags() {
+				err := emitUnknownTags(runner, r, config.OnUnknown, resource.Address(), tags)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if resource.InheritsDefaultTags && unknownDefaultTags[resource.ProviderAlias] {
+			continue
+		}
+
+		err := r.verifyTagCount(runner, config, inheritedTags(defaultTags, resource), resource)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Counts the tags a resource ends up with once the provider default_tags are merged in and reports if there are too many
+func (r *TagCountLimitRule) verifyTagCount(runner tflint.Runner, config *TagCountLimitRuleConfig, defaultTags map[string]string, resource *tagging.Resource) error {
+	issueRange := resource.Block.DefRange
+	if ownTags := resource.OwnTags(); len(ownTags) > 0 {
+		issueRange = ownTags[0].Range
+	}
+	resourceTags := resource.Values()
+
+	overlapping := 0
+	for key := range resourceTags {
+		if _, exists := defaultTags[key]; exists {
+			overlapping++
+		}
+	}
+	total := len(defaultTags) + len(resourceTags) - overlapping
+
+	sources := fmt.Sprintf("%d from provider default_tags, %d from resource tags", len(defaultTags), len(resourceTags))
+	if overlapping > 0 {
+		sources += fmt.Sprintf(", %d set in both", overlapping)
+	}
+
+	switch {
+	case total > config.Limit:
+		return runner.EmitIssue(
+			r.withViolation("tag_limit_exceeded"),
+			fmt.Sprintf("%s has %d tags (%s), exceeding the limit of %d", resource.Address(), total, sources, config.Limit),
+			issueRange,
+		)
+	case total >= config.WarnAt:
+		return runner.EmitIssue(
+			utils.WithSeverity(r.withViolation("tag_limit_close"), tflint.WARNING),
+			fmt.Sprintf("%s has %d tags (%s), close to the limit of %d", resource.Address(), total, sources, config.Limit),
+			issueRange,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+func Test_TagCountLimitRule(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Content  string
+		Config   string
+		Expected helper.Issues
+	}{
+		{
+			Name: "Succeeds_ForResource_BelowLimit",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team = "platform-engineering",
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					name = "web"
+				}
+			}`,
+			Config: `
+			rule "tag_count_limit" {
+				enabled = true
+				limit   = 4
+				warn_at = 3
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForResource_ExceedingLimit_WithDefaultTags",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team        = "platform-engineering",
+						environment = "prod",
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					name = "web"
+					team = "platform-engineering"
+					role = "frontend"
+				}
+			}`,
+			Config: `
+			rule "tag_count_limit" {
+				enabled = true
+				limit   = 3
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewTagCountLimitRule(),
+					Message: "aws_instance.ec2_instance has 4 tags (2 from provider default_tags, 3 from resource tags, 1 set in both), exceeding the limit of 3",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 13, Column: 5},
+						End:      hcl.Pos{Line: 17, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Warns_ForResource_CloseToLimit",
+			Content: `
+			resource "aws_s3_bucket" "bucket" {
+				tags = {
+					name = "logs"
+					team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "tag_count_limit" {
+				enabled = true
+				limit   = 3
+				warn_at = 2
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    utils.WithSeverity(NewTagCountLimitRule(), tflint.WARNING),
+					Message: "aws_s3_bucket.bucket has 2 tags (0 from provider default_tags, 2 from resource tags), close to the limit of 3",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 5},
+						End:      hcl.Pos{Line: 6, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForResource_UsingAliasedProvider",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+			}
+
+			provider "aws" {
+				alias  = "us"
+				region = "us-east-1"
+				default_tags {
+					tags = {
+						team        = "platform-engineering",
+						environment = "prod",
+					}
+				}
+			}
+
+			resource "aws_s3_bucket" "bucket" {
+				provider = aws.us
+			}`,
+			Config: `
+			rule "tag_count_limit" {
+				enabled = true
+				limit   = 1
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewTagCountLimitRule(),
+					Message: "aws_s3_bucket.bucket has 2 tags (2 from provider default_tags, 0 from resource tags), exceeding the limit of 1",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 17, Column: 4},
+						End:      hcl.Pos{Line: 17, Column: 37},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForProvider_WithUnknownDefaultTags_AsError",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = aws_s3_bucket.other.tags
+				}
+			}
+
+			resource "aws_s3_bucket" "bucket" {
+				tags = {
+					name = "logs"
+					team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "tag_count_limit" {
+				enabled    = true
+				limit      = 2
+				on_unknown = "error"
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    utils.WithSeverity(NewTagCountLimitRule(), tflint.ERROR),
+					Message: "The default_tags.tags of the aws provider could not be verified, as aws_s3_bucket.other.tags could not be evaluated",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 6},
+						End:      hcl.Pos{Line: 5, Column: 37},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForResource_WithUnknownTags_AsNotice",
+			Content: `
+			resource "aws_s3_bucket" "bucket" {
+				tags = aws_s3_bucket.other.tags
+			}`,
+			Config: `
+			rule "tag_count_limit" {
+				enabled    = true
+				on_unknown = "notice"
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    utils.WithSeverity(NewTagCountLimitRule(), tflint.NOTICE),
+					Message: "The tags of aws_s3_bucket.bucket could not be verified, as aws_s3_bucket.other.tags could not be evaluated",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 5},
+						End:      hcl.Pos{Line: 3, Column: 36},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForResource_ExceedingLimit_ButExcluded",
+			Content: `
+			resource "aws_s3_bucket" "bucket" {
+				tags = {
+					name = "logs"
+					team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "tag_count_limit" {
+				enabled = true
+				limit   = 1
+				exclude = ["aws_s3_bucket"]
+			}`,
+			Expected: helper.Issues{},
+		},
+	}
+
+	rule := NewTagCountLimitRule()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{"resource.tf": test.Content, ".tflint.hcl": test.Config})
+
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+
+			helper.AssertIssues(t, test.Expected, runner.Issues)
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/project"
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// TagCoverageRule definition
+type TagCoverageRule struct {
+	tflint.DefaultRule
+	utils.Violations
+
+	// The summary of every module checked during the run by module path, e.g. module.network. TFLint checks the modules
+	// the configuration calls one by one before the root module, so the coverage is complete once the root module is checked.
+	modules map[string]*tagCoverageSummary
+}
+
+// TagCoverageRuleConfig is a config of TagCoverageRule
+type TagCoverageRuleConfig struct {
+	Tags                []string          `hclext:"tags"`
+	Exclude             []string          `hclext:"exclude,optional"`
+	KubernetesLabelKeys map[string]string `hclext:"kubernetes_label_keys,optional"`
+	Output              string            `hclext:"output,optional"`
+}
+
+// tagCoverageSummary is the coverage of the required tags over the taggable resources of the configuration.
+// Resources whose tags could not be evaluated are counted as unverifiable and left out of the coverage.
+type tagCoverageSummary struct {
+	Resources     int                              `json:"resources"`
+	Unverifiable  int                              `json:"unverifiable"`
+	Tags          map[string]*tagCoverage          `json:"tags"`
+	ResourceTypes map[string]*resourceTypeCoverage `json:"resource_types"`
+}
+
+// resourceTypeCoverage is the coverage of the required tags over the resources of one type
+type resourceTypeCoverage struct {
+	Resources    int                     `json:"resources"`
+	Unverifiable int                     `json:"unverifiable"`
+	Tags         map[string]*tagCoverage `json:"tags"`
+}
+
+// tagCoverage is the number of verifiable resources with a required tag. Percentage is nil if no resource could be verified.
+type tagCoverage struct {
+	Tagged     int      `json:"tagged"`
+	Verifiable int      `json:"verifiable"`
+	Percentage *float64 `json:"percentage"`
+}
+
+// NewTagCoverageRule returns a new rule
+func NewTagCoverageRule() *TagCoverageRule {
+	return &TagCoverageRule{}
+}
+
+// Returns a copy of the rule with the violation of the issue it emits attached
+func (r *TagCoverageRule) withViolation(kind string, tags ...string) tflint.Rule {
+	return &TagCoverageRule{Violations: utils.NewViolations(kind, tags...)}
+}
+
+// Name returns the rule name
+func (r *TagCoverageRule) Name() string {
+	return "tag_coverage"
+}
+
+// Enabled returns whether the rule is enabled by default
+func (r *TagCoverageRule) Enabled() bool {
+	return false
+}
+
+// Severity returns the rule severity
+func (r *TagCoverageRule) Severity() tflint.Severity {
+	return tflint.NOTICE
+}
+
+// Link returns the rule reference link
+func (r *TagCoverageRule) Link() string {
+	return project.ReferenceLink(r.Name())
+}
+
+// Checks the rule
+func (r *TagCoverageRule) Check(runner tflint.Runner) error {
+	config := &TagCoverageRuleConfig{}
+	if err := runner.DecodeRuleConfig(r.Name(), config); err != nil {
+		return err
+	}
+
+	path, err := runner.GetModulePath()
+	if err != nil {
+		return err
+	}
+	moduleSummary := newTagCoverageSummary()
+	if err := r.countModule(runner, config, moduleSummary); err != nil {
+		return err
+	}
+	if r.modules == nil {
+		r.modules = map[string]*tagCoverageSummary{}
+	}
+	r.modules[path.String()] = moduleSummary
+
+	summary := newTagCoverageSummary()
+	for _, module := range utils.SortedKeys(r.modules) {
+		summary.add(r.modules[module])
+	}
+	summary.computePercentages()
+	if path.IsRoot() {
+		r.modules = nil
+	}
+
+	// The output is written for every module, so it holds the coverage of the modules checked so far
+	if config.Output != "" {
+		err := r.writeSummary(runner, config.Output, summary)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The notice is reported once, on the root module
+	if !path.IsRoot() || summary.Resources == 0 {
+		return nil
+	}
+	issueRange, err := moduleStart(runner)
+	if err != nil {
+		return err
+	}
+	return runner.EmitIssue(r.withViolation("tag_coverage"), summary.message(config.Tags), issueRange)
+}
+
+// Returns an empty summary
+func newTagCoverageSummary() *tagCoverageSummary {
+	return &tagCoverageSummary{Tags: map[string]*tagCoverage{}, ResourceTypes: map[string]*resourceTypeCoverage{}}
+}
+
+// Counts the required tags on the taggable resources of a single module
+func (r *TagCoverageRule) countModule(runner tflint.Runner, config *TagCoverageRuleConfig, summary *tagCoverageSummary) error {
+	for _, provider := range tagging.Providers {
+		defaultTags, err := getDefaultTags(runner, provider)
+		if err != nil {
+			return err
+		}
+
+		// Resources of provider configurations with default tags that could not be evaluated can't be verified either
+		unknownDefaults := map[string]bool{}
+		if provider.DefaultTags() != nil {
+			providerConfigs, err := tagging.GetProviderConfigs(runner, provider)
+			if err != nil {
+				return err
+			}
+			for _, providerConfig := range providerConfigs {
+				for _, tags := range providerConfig.DefaultTags {
+					if !tags.Known() {
+						unknownDefaults[providerConfig.Alias] = true
+					}
+				}
+			}
+		}
+
+		resources, err := tagging.GetResources(runner, provider, config.Exclude)
+		if err != nil {
+			return err
+		}
+
+		for _, resource := range resources {
+			typeCoverage, exists := summary.ResourceTypes[resource.Type]
+			if !exists {
+				typeCoverage = &resourceTypeCoverage{Tags: map[string]*tagCoverage{}}
+				summary.ResourceTypes[resource.Type] = typeCoverage
+			}
+			summary.Resources++
+			typeCoverage.Resources++
+
+			if !resource.Known() || unknownDefaults[resource.ProviderAlias] {
+				summary.Unverifiable++
+				typeCoverage.Unverifiable++
+				continue
+			}
+
+			tags := resource.Values()
+			for key, value := range inheritedTags(defaultTags, resource) {
+				tags[key] = value
+			}
+
+			for _, tag := range config.Tags {
+				_, tagged := tags[providerTagKey(provider, tag, config.KubernetesLabelKeys)]
+				for _, coverages := range []map[string]*tagCoverage{summary.Tags, typeCoverage.Tags} {
+					coverage, exists := coverages[tag]
+					if !exists {
+						coverage = &tagCoverage{}
+						coverages[tag] = coverage
+					}
+					coverage.Verifiable++
+					if tagged {
+						coverage.Tagged++
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Writes the summary as JSON to the path, relative to the directory TFLint runs in
+func (r *TagCoverageRule) writeSummary(runner tflint.Runner, path string, summary *tagCoverageSummary) error {
+	if !filepath.IsAbs(path) {
+		wd, err := runner.GetOriginalwd()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(wd, path)
+	}
+
+	content, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0o644)
+}
+
+// Adds the counts of the other summary to the summary
+func (s *tagCoverageSummary) add(other *tagCoverageSummary) {
+	s.Resources += other.Resources
+	s.Unverifiable += other.Unverifiable
+	addTagCoverages(s.Tags, other.Tags)
+
+	for resourceType, otherCoverage := range other.ResourceTypes {
+		typeCoverage, exists := s.ResourceTypes[resourceType]
+		if !exists {
+			typeCoverage = &resourceTypeCoverage{Tags: map[string]*tagCoverage{}}
+			s.ResourceTypes[resourceType] = typeCoverage
+		}
+		typeCoverage.Resources += otherCoverage.Resources
+		typeCoverage.Unverifiable += otherCoverage.Unverifiable
+		addTagCoverages(typeCoverage.Tags, otherCoverage.Tags)
+	}
+}
+
+// Adds the counts of the other tag coverages to the tag coverages
+func addTagCoverages(coverages map[string]*tagCoverage, other map[string]*tagCoverage) {
+	for tag, otherCoverage := range other {
+		coverage, exists := coverages[tag]
+		if !exists {
+			coverage = &tagCoverage{}
+			coverages[tag] = coverage
+		}
+		coverage.Tagged += otherCoverage.Tagged
+		coverage.Verifiable += otherCoverage.Verifiable
+	}
+}
+
+// Computes the percentages of every tag coverage from the counts
+func (s *tagCoverageSummary) computePercentages() {
+	coverages := []map[string]*tagCoverage{s.Tags}
+	for _, typeCoverage := range s.ResourceTypes {
+		coverages = append(coverages, typeCoverage.Tags)
+	}
+
+	for _, tags := range coverages {
+		for _, coverage := range tags {
+			if coverage.Verifiable > 0 {
+				percentage := float64(coverage.Tagged) * 100 / float64(coverage.Verifiable)
+				coverage.Percentage = &percentage
+			}
+		}
+	}
+}
+
+// Returns the summary as the message of an issue, e.g.
+// Tag coverage of 3 resources, 1 could not be verified: "team" 50.0% (1/2). By resource type: aws_s3_bucket (3 resources, 1 could not be verified): "team" 50.0% (1/2)
+func (s *tagCoverageSummary) message(required []string) string {
+	byType := []string{}
+	for _, resourceType := range utils.SortedKeys(s.ResourceTypes) {
+		typeCoverage := s.ResourceTypes[resourceType]
+		byType = append(byType, fmt.Sprintf("%s (%s): %s", resourceType, resourceCount(typeCoverage.Resources, typeCoverage.Unverifiable), tagCoverageText(required, typeCoverage.Tags)))
+	}
+
+	return fmt.Sprintf("Tag coverage of %s: %s. By resource type: %s", resourceCount(s.Resources, s.Unverifiable), tagCoverageText(required, s.Tags), strings.Join(byType, "; "))
+}
+
+// Returns the number of resources and how many of them could not be verified, e.g. 3 resources, 1 could not be verified
+func resourceCount(resources int, unverifiable int) string {
+	text := fmt.Sprintf("%d resources", resources)
+	if resources == 1 {
+		text = "1 resource"
+	}
+	if unverifiable > 0 {
+		text += fmt.Sprintf(", %d could not be verified", unverifiable)
+	}
+	return text
+}
+
+// Returns the coverage of the required tags in the order they are required in, e.g. "team" 50.0% (1/2), "env" 100.0% (2/2)
+func tagCoverageText(required []string, coverages map[string]*tagCoverage) string {
+	texts := []string{}
+	for _, tag := range required {
+		coverage, exists := coverages[tag]
+		if !exists || coverage.Percentage == nil {
+			texts = append(texts, fmt.Sprintf("\"%s\" n/a", tag))
+			continue
+		}
+		texts = append(texts, fmt.Sprintf("\"%s\" %.1f%% (%d/%d)", tag, *coverage.Percentage, coverage.Tagged, coverage.Verifiable))
+	}
+	if len(texts) == 0 {
+		return "no required tags"
+	}
+	return strings.Join(texts, ", ")
+}
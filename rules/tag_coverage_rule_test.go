@@ -0,0 +1,173 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_TagCoverageRule(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Content  string
+		Config   string
+		Expected helper.Issues
+	}{
+		{
+			Name: "Succeeds_ForModule_WithoutTaggableResources",
+			Content: `
+			resource "aws_iam_role_policy_attachment" "a" {
+				role       = "role"
+				policy_arn = "arn"
+			}`,
+			Config: `
+			rule "tag_coverage" {
+				enabled = true
+				tags    = ["team"]
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Reports_Coverage_PerTagAndResourceType",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						env = "prod"
+					}
+				}
+			}
+
+			resource "aws_s3_bucket" "logs" {
+				tags = {
+					team = "platform-engineering"
+				}
+			}
+
+			resource "aws_s3_bucket" "data" {
+			}
+
+			resource "aws_s3_bucket" "unknown" {
+				tags = aws_s3_bucket.logs.tags
+			}
+
+			resource "google_storage_bucket" "b" {
+				count = 2
+				labels = {
+					team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "tag_coverage" {
+				enabled = true
+				tags    = ["team", "env"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewTagCoverageRule(),
+					Message: "Tag coverage of 5 resources, 1 could not be verified: \"team\" 75.0% (3/4), \"env\" 50.0% (2/4). By resource type: aws_s3_bucket (3 resources, 1 could not be verified): \"team\" 50.0% (1/2), \"env\" 100.0% (2/2); google_storage_bucket (2 resources): \"team\" 100.0% (2/2), \"env\" 0.0% (0/2)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 1, Column: 1},
+						End:      hcl.Pos{Line: 1, Column: 1},
+					},
+				},
+			},
+		},
+		{
+			Name: "Reports_Coverage_WithUnknownDefaultTags",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = local.tags
+				}
+			}
+
+			resource "aws_s3_bucket" "logs" {
+				tags = {
+					team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "tag_coverage" {
+				enabled = true
+				tags    = ["team"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewTagCoverageRule(),
+					Message: "Tag coverage of 1 resource, 1 could not be verified: \"team\" n/a. By resource type: aws_s3_bucket (1 resource, 1 could not be verified): \"team\" n/a",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 1, Column: 1},
+						End:      hcl.Pos{Line: 1, Column: 1},
+					},
+				},
+			},
+		},
+	}
+
+	rule := NewTagCoverageRule()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			runner := newTestRunner(t, map[string]string{"resource.tf": test.Content, ".tflint.hcl": test.Config})
+
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+
+			helper.AssertIssues(t, test.Expected, runner.Issues)
+		})
+	}
+}
+
+func Test_TagCoverageRule_Output(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "coverage.json")
+	runner := helper.TestRunner(t, map[string]string{
+		"resource.tf": `
+		resource "aws_s3_bucket" "logs" {
+			tags = {
+				team = "platform-engineering"
+			}
+		}
+
+		resource "aws_s3_bucket" "data" {
+			tags = aws_s3_bucket.logs.tags
+		}`,
+		".tflint.hcl": fmt.Sprintf(`
+		rule "tag_coverage" {
+			enabled = true
+			tags    = ["team"]
+			output  = "%s"
+		}`, output),
+	})
+
+	if err := NewTagCoverageRule().Check(runner); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+	var summary tagCoverageSummary
+	if err := json.Unmarshal(content, &summary); err != nil {
+		t.Fatalf("Unexpected error occurred: %s", err)
+	}
+
+	if summary.Resources != 2 || summary.Unverifiable != 1 {
+		t.Errorf("Expected 2 resources with 1 unverifiable, got %d with %d", summary.Resources, summary.Unverifiable)
+	}
+	coverage := summary.ResourceTypes["aws_s3_bucket"].Tags["team"]
+	if coverage.Tagged != 1 || coverage.Verifiable != 1 || coverage.Percentage == nil || *coverage.Percentage != 100 {
+		t.Errorf("Expected team on 1 of 1 verifiable aws_s3_bucket, got %+v", coverage)
+	}
+}
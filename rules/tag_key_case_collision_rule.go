@@ -0,0 +1,198 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/project"
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"golang.org/x/exp/slices"
+)
+
+// TagKeyCaseCollisionRule definition
+type TagKeyCaseCollisionRule struct {
+	tflint.DefaultRule
+	utils.Violations
+}
+
+// TagKeyCaseCollisionRuleConfig is a config of TagKeyCaseCollisionRule
+type TagKeyCaseCollisionRuleConfig struct {
+	Exclude []string `hclext:"exclude,optional"`
+}
+
+// taggedKey is a tag key together with where it was set
+type taggedKey struct {
+	Key    string
+	Source string
+	Range  hcl.Range
+}
+
+// NewTagKeyCaseCollisionRule returns a new rule
+func NewTagKeyCaseCollisionRule() *TagKeyCaseCollisionRule {
+	return &TagKeyCaseCollisionRule{}
+}
+
+// Returns a copy of the rule with the violation of the issue it emits attached
+func (r *TagKeyCaseCollisionRule) withViolation(kind string, tags ...string) tflint.Rule {
+	return &TagKeyCaseCollisionRule{Violations: utils.NewViolations(kind, tags...)}
+}
+
+// Name returns the rule name
+func (r *TagKeyCaseCollisionRule) Name() string {
+	return "tag_key_case_collision"
+}
+
+// Enabled returns whether the rule is enabled by default
+func (r *TagKeyCaseCollisionRule) Enabled() bool {
+	return false
+}
+
+// Severity returns the rule severity
+func (r *TagKeyCaseCollisionRule) Severity() tflint.Severity {
+	return tflint.WARNING
+}
+
+// Link returns the rule reference link
+func (r *TagKeyCaseCollisionRule) Link() string {
+	return project.ReferenceLink(r.Name())
+}
+
+// Checks the rule
+func (r *TagKeyCaseCollisionRule) Check(runner tflint.Runner) error {
+	config := &TagKeyCaseCollisionRuleConfig{}
+	if err := runner.DecodeRuleConfig(r.Name(), config); err != nil {
+		return err
+	}
+
+	for _, provider := range tagging.Providers {
+		err := r.checkProvider(runner, config, provider)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Checks the default tags and resource tags of the provider for keys that only differ in case
+func (r *TagKeyCaseCollisionRule) checkProvider(runner tflint.Runner, config *TagKeyCaseCollisionRuleConfig, provider tagging.Provider) error {
+	defaultTags, err := getDefaultTags(runner, provider)
+	if err != nil {
+		return err
+	}
+
+	// Collisions within the default tags of a provider are reported once on the provider
+	defaultTagKeys := map[string][]taggedKey{}
+	for _, alias := range utils.SortedKeys(defaultTags) {
+		defaultTagKeys[alias] = taggedKeys(defaultTags[alias], "provider "+defaultTagsName(provider))
+
+		err := r.emitCollisions(runner, provider, defaultTagKeys[alias], func(key taggedKey, group []taggedKey) bool { return true })
+		if err != nil {
+			return err
+		}
+	}
+
+	resources, err := tagging.GetResources(runner, provider, config.Exclude)
+	if err != nil {
+		return err
+	}
+
+	// Default tag keys colliding with the keys of a resource are reported on the provider once per provider configuration
+	reported := map[string]map[string]bool{}
+	for _, resource := range resources {
+		if !resource.Known() {
+			continue
+		}
+
+		source := fmt.Sprintf("%ss of %s", strings.ToLower(provider.Noun()), resource.Address())
+		keys := []taggedKey{}
+		for _, tags := range resource.OwnTags() {
+			keys = append(keys, taggedKeys(tags, source)...)
+		}
+		keys = append(keys, defaultTagKeys[resource.ProviderAlias]...)
+
+		// The collisions within the default tags were reported on the provider
+		alias := resource.ProviderAlias
+		err = r.emitCollisions(runner, provider, keys, func(key taggedKey, group []taggedKey) bool {
+			if key.Source == source {
+				return true
+			}
+			collides := slices.ContainsFunc(group, func(other taggedKey) bool { return other.Source == source && other.Key != key.Key })
+			if !collides || reported[alias][key.Key] {
+				return false
+			}
+			if reported[alias] == nil {
+				reported[alias] = map[string]bool{}
+			}
+			reported[alias][key.Key] = true
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the keys of the evaluated tags together with their location
+func taggedKeys(tags *tagging.Tags, source string) []taggedKey {
+	keys := []taggedKey{}
+	for _, key := range utils.SortedKeys(tags.Values) {
+		keys = append(keys, taggedKey{Key: key, Source: source, Range: tags.KeyRange(key)})
+	}
+	return keys
+}
+
+// Groups the keys by their lower case form and reports the keys accepted by report, given the group of the key, of every group
+// that is spelled in more than one way.
+// The same key set in two places, such as a resource overriding a default tag, is not a collision.
+func (r *TagKeyCaseCollisionRule) emitCollisions(runner tflint.Runner, provider tagging.Provider, keys []taggedKey, report func(key taggedKey, group []taggedKey) bool) error {
+	groups := map[string][]taggedKey{}
+	spellings := map[string]map[string]bool{}
+	for _, key := range keys {
+		name := strings.ToLower(key.Key)
+		groups[name] = append(groups[name], key)
+		if spellings[name] == nil {
+			spellings[name] = map[string]bool{}
+		}
+		spellings[name][key.Key] = true
+	}
+
+	for _, name := range utils.SortedKeys(groups) {
+		group := groups[name]
+		if len(spellings[name]) < 2 {
+			continue
+		}
+
+		for _, key := range group {
+			if !report(key, group) {
+				continue
+			}
+
+			collisions := []string{}
+			for _, other := range group {
+				if other.Key != key.Key {
+					collisions = append(collisions, fmt.Sprintf("\"%s\" (%s)", other.Key, other.Source))
+				}
+			}
+			if len(collisions) == 0 {
+				continue
+			}
+
+			err := runner.EmitIssue(
+				r.withViolation("case_collision", key.Key),
+				fmt.Sprintf("%s key \"%s\" (%s) only differs in case from %s", provider.Noun(), key.Key, key.Source, strings.Join(collisions, ", ")),
+				key.Range,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
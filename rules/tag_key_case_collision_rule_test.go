@@ -0,0 +1,318 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+)
+
+func Test_TagKeyCaseCollisionRule(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Content  string
+		Config   string
+		Expected helper.Issues
+	}{
+		{
+			Name: "Succeeds_ForResource_WithDistinctKeys",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team = "platform-engineering",
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					name = "web"
+					team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "tag_key_case_collision" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForResource_WithKeysDifferingInCase",
+			Content: `
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					Name = "web"
+					name = "web"
+				}
+			}`,
+			Config: `
+			rule "tag_key_case_collision" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewTagKeyCaseCollisionRule(),
+					Message: "Tag key \"Name\" (tags of aws_instance.ec2_instance) only differs in case from \"name\" (tags of aws_instance.ec2_instance)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 4, Column: 6},
+						End:      hcl.Pos{Line: 4, Column: 18},
+					},
+				},
+				{
+					Rule:    NewTagKeyCaseCollisionRule(),
+					Message: "Tag key \"name\" (tags of aws_instance.ec2_instance) only differs in case from \"Name\" (tags of aws_instance.ec2_instance)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 6},
+						End:      hcl.Pos{Line: 5, Column: 18},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForResource_WithKeyDifferingInCaseFromDefaultTags",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team = "platform-engineering",
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					Team = "platform-engineering"
+				}
+			}
+
+			resource "aws_s3_bucket" "bucket" {
+				tags = {
+					Team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "tag_key_case_collision" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewTagKeyCaseCollisionRule(),
+					Message: "Tag key \"Team\" (tags of aws_instance.ec2_instance) only differs in case from \"team\" (provider default_tags)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 13, Column: 6},
+						End:      hcl.Pos{Line: 13, Column: 35},
+					},
+				},
+				{
+					Rule:    NewTagKeyCaseCollisionRule(),
+					Message: "Tag key \"team\" (provider default_tags) only differs in case from \"Team\" (tags of aws_instance.ec2_instance)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 6, Column: 7},
+						End:      hcl.Pos{Line: 6, Column: 36},
+					},
+				},
+				{
+					Rule:    NewTagKeyCaseCollisionRule(),
+					Message: "Tag key \"Team\" (tags of aws_s3_bucket.bucket) only differs in case from \"team\" (provider default_tags)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 19, Column: 6},
+						End:      hcl.Pos{Line: 19, Column: 35},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForResourcesOfAliasedProviders_WithKeyDifferingInCaseFromDefaultTags",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team = "platform-engineering",
+					}
+				}
+			}
+
+			provider "aws" {
+				alias  = "west"
+				region = "us-west-2"
+				default_tags {
+					tags = {
+						team = "platform-engineering",
+					}
+				}
+			}
+
+			resource "aws_s3_bucket" "bucket" {
+				tags = {
+					Team = "platform-engineering"
+				}
+			}
+
+			resource "aws_s3_bucket" "west" {
+				provider = aws.west
+				tags = {
+					Team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "tag_key_case_collision" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewTagKeyCaseCollisionRule(),
+					Message: "Tag key \"Team\" (tags of aws_s3_bucket.bucket) only differs in case from \"team\" (provider default_tags)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 23, Column: 6},
+						End:      hcl.Pos{Line: 23, Column: 35},
+					},
+				},
+				{
+					Rule:    NewTagKeyCaseCollisionRule(),
+					Message: "Tag key \"team\" (provider default_tags) only differs in case from \"Team\" (tags of aws_s3_bucket.bucket)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 6, Column: 7},
+						End:      hcl.Pos{Line: 6, Column: 36},
+					},
+				},
+				{
+					Rule:    NewTagKeyCaseCollisionRule(),
+					Message: "Tag key \"Team\" (tags of aws_s3_bucket.west) only differs in case from \"team\" (provider default_tags)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 30, Column: 6},
+						End:      hcl.Pos{Line: 30, Column: 35},
+					},
+				},
+				{
+					Rule:    NewTagKeyCaseCollisionRule(),
+					Message: "Tag key \"team\" (provider default_tags) only differs in case from \"Team\" (tags of aws_s3_bucket.west)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 16, Column: 7},
+						End:      hcl.Pos{Line: 16, Column: 36},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForProvider_WithKeysDifferingInCase",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team = "platform-engineering",
+						TEAM = "platform-engineering",
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					name = "web"
+				}
+			}
+
+			resource "aws_s3_bucket" "bucket" {
+				tags = {
+					name = "logs"
+				}
+			}`,
+			Config: `
+			rule "tag_key_case_collision" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewTagKeyCaseCollisionRule(),
+					Message: "Tag key \"TEAM\" (provider default_tags) only differs in case from \"team\" (provider default_tags)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 7, Column: 7},
+						End:      hcl.Pos{Line: 7, Column: 36},
+					},
+				},
+				{
+					Rule:    NewTagKeyCaseCollisionRule(),
+					Message: "Tag key \"team\" (provider default_tags) only differs in case from \"TEAM\" (provider default_tags)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 6, Column: 7},
+						End:      hcl.Pos{Line: 6, Column: 36},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForResource_OverridingDefaultTag",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						team = "platform-engineering",
+					}
+				}
+			}
+
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					team = "voyage-optimization"
+				}
+			}
+
+			resource "aws_s3_bucket" "bucket" {
+				tags = {
+					team = "voyage-optimization"
+				}
+			}`,
+			Config: `
+			rule "tag_key_case_collision" {
+				enabled = true
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Succeeds_ForResource_WithKeysDifferingInCase_ButExcluded",
+			Content: `
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					Name = "web"
+					name = "web"
+				}
+			}`,
+			Config: `
+			rule "tag_key_case_collision" {
+				enabled = true
+				exclude = ["aws_instance"]
+			}`,
+			Expected: helper.Issues{},
+		},
+	}
+
+	rule := NewTagKeyCaseCollisionRule()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{"resource.tf": test.Content, ".tflint.hcl": test.Config})
+
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+
+			helper.AssertIssues(t, test.Expected, runner.Issues)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// TagPolicyConfig is a custom policy, an HCL expression over the tags and the resource that has to be true for every resource
+type TagPolicyConfig struct {
+	Name      string `hclext:"name,label"`
+	Condition string `hclext:"condition"`
+	Message   string `hclext:"message"`
+	Severity  string `hclext:"severity,optional"`
+}
+
+// tagPolicy is a policy with its condition parsed and type-checked
+type tagPolicy struct {
+	TagPolicyConfig
+	condition hcl.Expression
+	// severity is only used if the policy sets one
+	severity tflint.Severity
+	ctx      *hcl.EvalContext
+}
+
+// The variables every policy can refer to besides the policy variables of the rule
+const (
+	policyTagsVariable     = "tags"
+	policyResourceVariable = "resource"
+)
+
+// The type of the resource variable of policies
+var policyResourceType = cty.Object(map[string]cty.Type{
+	"type":           cty.String,
+	"name":           cty.String,
+	"address":        cty.String,
+	"provider_alias": cty.String,
+})
+
+// violationRule is a rule that can attach the violation to the issues it emits
+type violationRule interface {
+	tflint.Rule
+	withViolation(kind string, tags ...string) tflint.Rule
+}
+
+// Parses and type-checks the policies of a rule config. The conditions are evaluated with unknown tags and resource,
+// so references to variables that don't exist, calls to unknown functions and conditions that aren't bool are found
+// before any resource is checked.
+func compileTagPolicies(configs []TagPolicyConfig, variables cty.Value) ([]*tagPolicy, error) {
+	ctxVariables := map[string]cty.Value{}
+	if variables != cty.NilVal && !variables.IsNull() {
+		if !variables.Type().IsObjectType() && !variables.Type().IsMapType() {
+			return nil, fmt.Errorf("policy_variables should be an object, got %s", variables.Type().FriendlyName())
+		}
+		for name, value := range variables.AsValueMap() {
+			if name == policyTagsVariable || name == policyResourceVariable {
+				return nil, fmt.Errorf("policy_variables can't contain \"%s\", it is the %s of the resource", name, name)
+			}
+			ctxVariables[name] = value
+		}
+	}
+
+	policies := []*tagPolicy{}
+	for _, config := range configs {
+		policy := &tagPolicy{TagPolicyConfig: config}
+
+		switch strings.ToLower(config.Severity) {
+		case "", "error":
+			policy.severity = tflint.ERROR
+		case "warning":
+			policy.severity = tflint.WARNING
+		case "notice":
+			policy.severity = tflint.NOTICE
+		default:
+			return nil, fmt.Errorf("unknown severity \"%s\" in policy \"%s\", valid severities are \"error\", \"warning\" and \"notice\"", config.Severity, config.Name)
+		}
+
+		condition, diags := hclsyntax.ParseExpression([]byte(config.Condition), "condition", hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid condition in policy \"%s\": %s", config.Name, diags.Error())
+		}
+		policy.condition = condition
+		policy.ctx = &hcl.EvalContext{Variables: ctxVariables, Functions: policyFunctions}
+
+		for _, traversal := range condition.Variables() {
+			name := traversal.RootName()
+			if _, exists := ctxVariables[name]; !exists && name != policyTagsVariable && name != policyResourceVariable {
+				return nil, fmt.Errorf("invalid condition in policy \"%s\": unknown variable \"%s\", conditions can refer to tags, resource and the policy_variables", config.Name, name)
+			}
+		}
+
+		value, diags := policy.evaluate(cty.UnknownVal(cty.Map(cty.String)), cty.UnknownVal(policyResourceType))
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid condition in policy \"%s\": %s", config.Name, diags.Error())
+		}
+		// The type of some conditions, such as ones using try, is only known for a resource
+		if value.Type() != cty.Bool && value.Type() != cty.DynamicPseudoType {
+			return nil, fmt.Errorf("invalid condition in policy \"%s\": it should be a bool, got %s", config.Name, value.Type().FriendlyName())
+		}
+
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// Evaluates the condition of the policy for the tags and the resource
+func (p *tagPolicy) evaluate(tags cty.Value, resource cty.Value) (cty.Value, hcl.Diagnostics) {
+	ctx := p.ctx.NewChild()
+	ctx.Variables = map[string]cty.Value{
+		policyTagsVariable:     tags,
+		policyResourceVariable: resource,
+	}
+	return p.condition.Value(ctx)
+}
+
+// Returns whether the resource with the tags satisfies the policy. It returns an error if the condition can't be evaluated,
+// such as one referring to a tag the resource doesn't have outside of can, try or has, or if it isn't a bool.
+func (p *tagPolicy) satisfied(tags map[string]string, resource *tagging.Resource) (bool, error) {
+	tagValues := map[string]cty.Value{}
+	for key, value := range tags {
+		tagValues[key] = cty.StringVal(value)
+	}
+	tagsValue := cty.MapValEmpty(cty.String)
+	if len(tagValues) > 0 {
+		tagsValue = cty.MapVal(tagValues)
+	}
+
+	resourceValue := cty.ObjectVal(map[string]cty.Value{
+		"type":           cty.StringVal(resource.Type),
+		"name":           cty.StringVal(resource.Name),
+		"address":        cty.StringVal(resource.Address()),
+		"provider_alias": cty.StringVal(resource.ProviderAlias),
+	})
+
+	value, diags := p.evaluate(tagsValue, resourceValue)
+	if diags.HasErrors() {
+		return false, diags
+	}
+	if value.Type() != cty.Bool || !value.IsKnown() || value.IsNull() {
+		return false, fmt.Errorf("it should be a bool, got %s", valueDescription(value))
+	}
+	return value.True(), nil
+}
+
+// Describes a value that isn't a known bool, e.g. a null bool or a string
+func valueDescription(value cty.Value) string {
+	switch {
+	case value.IsNull():
+		return "null"
+	case !value.IsKnown():
+		return "an unknown value"
+	}
+	return value.Type().FriendlyName()
+}
+
+// Verifies that the tags the resource ends up with satisfy the policies, reporting the message of those that aren't.
+// A policy whose condition can't be evaluated for the resource is reported with the error, and the other policies are still verified.
+func verifyTagPolicies(runner tflint.Runner, rule violationRule, policies []*tagPolicy, provider tagging.Provider, resource *tagging.Resource) error {
+	if len(policies) == 0 {
+		return nil
+	}
+	tags, known, err := effectiveTags(runner, provider, resource)
+	if err != nil || !known {
+		return err
+	}
+
+	issueRange := resource.Block.DefRange
+	if ownTags := resource.OwnTags(); len(ownTags) > 0 {
+		issueRange = ownTags[0].ValueRange
+	}
+
+	for _, policy := range policies {
+		satisfied, err := policy.satisfied(tags, resource)
+		if err != nil {
+			err := runner.EmitIssue(
+				rule.withViolation("policy_error:"+policy.Name),
+				resourceMessage(resource, fmt.Sprintf("The condition of policy \"%s\" could not be evaluated: %s", policy.Name, err)),
+				issueRange,
+			)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if satisfied {
+			continue
+		}
+
+		// Policies without a severity are reported with the severity of the rule
+		issueRule := rule.withViolation("policy:" + policy.Name)
+		if policy.Severity != "" {
+			issueRule = utils.WithSeverity(issueRule, policy.severity)
+		}
+		err = runner.EmitIssue(issueRule, resourceMessage(resource, policy.Message), issueRange)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+)
+
+// What a rule does with tags it cannot evaluate, configured with on_unknown
+const (
+	onUnknownIgnore = "ignore"
+	onUnknownNotice = "notice"
+	onUnknownError  = "error"
+)
+
+var onUnknownValues = []string{onUnknownIgnore, onUnknownNotice, onUnknownError}
+
+// Returns the on_unknown behavior, defaulting to ignore, or an error if it isn't one of the known values
+func resolveOnUnknown(onUnknown string) (string, error) {
+	switch onUnknown {
+	case "":
+		return onUnknownIgnore, nil
+	case onUnknownIgnore, onUnknownNotice, onUnknownError:
+		return onUnknown, nil
+	}
+	return "", fmt.Errorf("unknown on_unknown value \"%s\", valid values are \"%s\"", onUnknown, strings.Join(onUnknownValues, "\", \""))
+}
+
+// Reports tags that could not be evaluated and so were not verified, as a notice or an error depending on on_unknown.
+// The owner names what the tags belong to, e.g. aws_s3_bucket.b or the aws provider.
+func emitUnknownTags(runner tflint.Runner, rule violationRule, onUnknown string, owner string, tags *tagging.Tags) error {
+	if tags.Known() {
+		return nil
+	}
+
+	var severity tflint.Severity
+	switch onUnknown {
+	case onUnknownNotice:
+		severity = tflint.NOTICE
+	case onUnknownError:
+		severity = tflint.ERROR
+	default:
+		return nil
+	}
+
+	location := tags.Location.String()
+	if tags.Location.Format == tagging.BlockFormat {
+		location += " blocks"
+	}
+	reason := fmt.Sprintf("the dynamic \"%s\" blocks could not be expanded", tags.Location.Name)
+	if tags.Expr != nil {
+		expression, err := sourceText(runner, tags)
+		if err != nil {
+			return err
+		}
+		reason = fmt.Sprintf("%s could not be evaluated", expression)
+	}
+
+	return runner.EmitIssue(
+		utils.WithSeverity(rule.withViolation("unknown_tags"), severity),
+		fmt.Sprintf("The %s of %s could not be verified, as %s", location, owner, reason),
+		tags.Range,
+	)
+}
+
+// Returns the source of the tags expression on a single line
+func sourceText(runner tflint.Runner, tags *tagging.Tags) (string, error) {
+	file, err := runner.GetFile(tags.ValueRange.Filename)
+	if err != nil {
+		return "", err
+	}
+	if file == nil {
+		return "the expression", nil
+	}
+	return strings.Join(strings.Fields(string(tags.ValueRange.SliceBytes(file.Bytes))), " "), nil
+}
@@ -4,16 +4,21 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 	"github.com/terraform-linters/tflint-ruleset-aws/project"
-	"github.com/terraform-linters/tflint-ruleset-aws/rules/tags"
+	"github.com/zclconf/go-cty/cty"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 )
 
 // ValidateTagsRule definition
 type ValidateTagsRule struct {
 	tflint.DefaultRule
+	utils.Violations
 }
 
 // ValidateTagsRuleConfig is a config of ValidateTagsRule
@@ -22,7 +27,20 @@ type ValidateTagsRuleConfig struct {
 		Tag           string   `cty:"tag"`
 		AllowedValues []string `cty:"allowed_values"`
 	} `hclext:"tags"`
-	Exclude []string `hclext:"exclude,optional"`
+	ExcludeEntries      cty.Value             `hclext:"exclude,optional"`
+	KubernetesLabelKeys map[string]string     `hclext:"kubernetes_label_keys,optional"`
+	ModuleInputs        []string              `hclext:"module_inputs,optional"`
+	OnUnknown           string                `hclext:"on_unknown,optional"`
+	Constraints         []TagConstraintConfig `hclext:"constraint,block"`
+	Conditions          []TagConditionConfig  `hclext:"condition,block"`
+	Policies            []TagPolicyConfig     `hclext:"policy,block"`
+	PolicyVariables     cty.Value             `hclext:"policy_variables,optional"`
+	// Exclude holds the resource types excluded by the entries of exclude that haven't expired
+	Exclude    []string
+	conditions []*tagCondition
+	policies   []*tagPolicy
+	// calledModule is whether the module is called from another module, which validates the tags it passes to the module inputs
+	calledModule bool
 }
 
 // NewValidateTagsRule returns a new rule
@@ -30,6 +48,11 @@ func NewValidateTagsRule() *ValidateTagsRule {
 	return &ValidateTagsRule{}
 }
 
+// Returns a copy of the rule with the violation of the issue it emits attached
+func (r *ValidateTagsRule) withViolation(kind string, tags ...string) tflint.Rule {
+	return &ValidateTagsRule{Violations: utils.NewViolations(kind, tags...)}
+}
+
 // Name returns the rule name
 func (r *ValidateTagsRule) Name() string {
 	return "validate_tags"
@@ -56,103 +79,245 @@ func (r *ValidateTagsRule) Check(runner tflint.Runner) error {
 	if err := runner.DecodeRuleConfig(r.Name(), config); err != nil {
 		return err
 	}
+	if len(config.ModuleInputs) == 0 {
+		config.ModuleInputs = []string{"tags"}
+	}
+	onUnknown, err := resolveOnUnknown(config.OnUnknown)
+	if err != nil {
+		return err
+	}
+	config.OnUnknown = onUnknown
+	config.Exclude, err = resolveExclude(runner, r, config.ExcludeEntries)
+	if err != nil {
+		return err
+	}
+	config.conditions, err = compileTagConditions(r.Name(), config.Conditions, true)
+	if err != nil {
+		return err
+	}
+	config.policies, err = compileTagPolicies(config.Policies, config.PolicyVariables)
+	if err != nil {
+		return err
+	}
+	path, err := runner.GetModulePath()
+	if err != nil {
+		return err
+	}
+	config.calledModule = !path.IsRoot()
 
-	// Check provider
-	providers, err := runner.GetProviderContent("aws", &hclext.BodySchema{
-		Blocks: []hclext.BlockSchema{
-			{
-				Type: "default_tags",
-				Body: &hclext.BodySchema{
-
-					Attributes: []hclext.AttributeSchema{
-						{
-							Name: "tags",
-						},
-					},
-				},
-			},
-		},
-	}, nil)
+	for _, provider := range tagging.Providers {
+		err := r.checkProvider(runner, config, provider)
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.checkModuleCalls(runner, config)
+}
+
+// Checks the default tags of the provider configurations and the tags of the resources of the provider
+func (r *ValidateTagsRule) checkProvider(runner tflint.Runner, config *ValidateTagsRuleConfig, provider tagging.Provider) error {
+	providerConfigs, err := tagging.GetProviderConfigs(runner, provider)
 	if err != nil {
 		return err
 	}
 
-	// Go through all providers
-	for _, provider := range providers.Blocks {
-		// Get the default_tags block on the provider
-		defaultTagsBlocks := provider.Body.Blocks.OfType("default_tags")
+	// Default tags end up on every resource, so they are held to the general constraints
+	constraint, err := resolveTagConstraint(provider, "", config.Constraints)
+	if err != nil {
+		return err
+	}
 
-		// Check for allowed tags
-		for _, defaultTagsBlock := range defaultTagsBlocks {
-			err := r.verifyValidTag(runner, config, defaultTagsBlock)
+	for _, providerConfig := range providerConfigs {
+		for _, tags := range providerConfig.DefaultTags {
+			err := r.verifyValidTags(runner, config, provider, constraint, fmt.Sprintf("the %s provider", provider.Name()), nil, tags)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	// Go through all resources
-	for _, resourceType := range tags.Resources {
-		// Skip this resource if its type is excluded in the configuration
-		if slices.Contains(config.Exclude, resourceType) {
-			continue
-		}
+	resources, err := tagging.GetResources(runner, provider, config.Exclude)
+	if err != nil {
+		return err
+	}
 
-		resources, err := runner.GetResourceContent(resourceType, &hclext.BodySchema{
-			Attributes: []hclext.AttributeSchema{{Name: "tags"}},
-		}, nil)
+	for _, resource := range resources {
+		constraint, err := resolveTagConstraint(provider, resource.Type, config.Constraints)
 		if err != nil {
 			return err
 		}
 
-		// Go through all resources and check for allowed tag values
-		for _, resource := range resources.Blocks {
-			err := r.verifyValidTag(runner, config, resource)
+		for _, tags := range resource.Tags {
+			err := r.verifyValidTags(runner, config, provider, constraint, resource.Address(), resource, tags)
 			if err != nil {
 				return err
 			}
 		}
+
+		err = r.verifyConditions(runner, config, provider, resource)
+		if err != nil {
+			return err
+		}
+
+		err = verifyTagPolicies(runner, r, config.policies, provider, resource)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// Takes a Terraform block and verifies that if one of the validated tags is present it has one of the valid values
-func (r *ValidateTagsRule) verifyValidTag(runner tflint.Runner, config *ValidateTagsRuleConfig, block *hclext.Block) error {
-	attribute, exists := block.Body.Attributes["tags"]
-	if !exists {
+// Verifies that the tags of the resource have the values required by the conditions its tags match.
+// Tags required by a condition that are missing are left to ensure_default_tags.
+func (r *ValidateTagsRule) verifyConditions(runner tflint.Runner, config *ValidateTagsRuleConfig, provider tagging.Provider, resource *tagging.Resource) error {
+	if len(config.conditions) == 0 {
 		return nil
 	}
+	tags, known, err := effectiveTags(runner, provider, resource)
+	if err != nil || !known {
+		return err
+	}
+
+	for _, condition := range config.conditions {
+		ifKey, thenKey := condition.keys(provider, config.KubernetesLabelKeys)
+		reason, applies := condition.applies(tags, ifKey)
+		if !applies {
+			continue
+		}
+		value, exists := tags[thenKey]
+		if !exists || condition.thenPattern.MatchString(value) {
+			continue
+		}
+
+		// The value is reported where the resource sets it, or on the resource if it comes from the default tags
+		issueRange := resource.Block.DefRange
+		for _, tags := range resource.OwnTags() {
+			issueRange = tags.Range
+			if _, exists := tags.Values[thenKey]; exists {
+				issueRange = tags.KeyRange(thenKey)
+				break
+			}
+		}
 
-	var tags map[string]string
-	err := runner.EvaluateExpr(attribute.Expr, &tags, nil)
+		err := runner.EmitIssue(
+			r.withViolation("conditional_value", thenKey),
+			resourceMessage(resource, fmt.Sprintf("%s value \"%s\" of \"%s\" does not match \"%s\", which is required as %s", provider.Noun(), value, thenKey, condition.ThenMatches, reason)),
+			issueRange,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Checks the tags passed to the module calls, so invalid values are reported where they are written rather than on the resources of the module.
+// The tags are held to the general constraints of the provider if the call passes only that provider, as a module can put its tags
+// on resources of any provider it is passed, and to the most permissive constraints otherwise.
+func (r *ValidateTagsRule) checkModuleCalls(runner tflint.Runner, config *ValidateTagsRuleConfig) error {
+	calls, err := tagging.GetModuleCalls(runner, config.ModuleInputs)
 	if err != nil {
-		return nil
+		return err
+	}
+
+	for _, call := range calls {
+		provider, constraint := tagging.AWS, permissiveTagConstraint
+		if len(call.Providers) == 1 {
+			for _, known := range tagging.Providers {
+				if known.Name() != call.Providers[0] {
+					continue
+				}
+				provider = known
+				constraint, err = resolveTagConstraint(known, "", config.Constraints)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, tags := range call.Tags {
+			err := r.verifyValidTags(runner, config, provider, constraint, "module."+call.Name, nil, tags)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Verifies that the tags satisfy the constraint and that if one of the validated tags is present it has one of the valid values.
+// The owner names what the tags belong to. The resource is nil for tags that don't belong to a resource, such as default tags.
+func (r *ValidateTagsRule) verifyValidTags(runner tflint.Runner, config *ValidateTagsRuleConfig, provider tagging.Provider, constraint tagConstraint, owner string, resource *tagging.Resource, tags *tagging.Tags) error {
+	if !tags.Known() {
+		return emitUnknownTags(runner, r, config.OnUnknown, owner, tags)
+	}
+
+	// Tags a called module gets through its inputs are already validated at the module call
+	inputTags := map[string]string{}
+	if config.calledModule && resource != nil {
+		inputTags = moduleInputTags(runner, config.ModuleInputs, tags)
 	}
 
-	err = runner.EnsureNoError(err, func() error {
-		for tag := range tags {
-			for _, validatedTag := range config.Tags {
-				if tag == validatedTag.Tag {
-					if !slices.Contains(validatedTag.AllowedValues, tags[tag]) {
-						err := runner.EmitIssue(
-							r,
-							fmt.Sprintf("Tag value \"%s\" is not allowed for tag \"%s\" (valid values are %s)", tags[tag], tag, "\""+strings.Join(validatedTag.AllowedValues, "\", "+"\"")+"\""),
-							attribute.Range,
-						)
-						if err != nil {
-							return err
-						}
+	for _, tag := range utils.SortedKeys(tags.Values) {
+		value := tags.Values[tag]
+		if inputValue, exists := inputTags[tag]; exists && inputValue == value {
+			continue
+		}
+		for _, violation := range constraint.violations(tag, value) {
+			err := runner.EmitIssue(r.withViolation("invalid_tag", tag), resourceMessage(resource, violation), tags.Range)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, validatedTag := range config.Tags {
+			if tag == providerTagKey(provider, validatedTag.Tag, config.KubernetesLabelKeys) {
+				if !slices.Contains(validatedTag.AllowedValues, value) {
+					err := runner.EmitIssue(
+						r.withViolation("disallowed_value", tag),
+						resourceMessage(resource, fmt.Sprintf("%s value \"%s\" is not allowed for %s \"%s\" (valid values are %s)", constraint.noun, value, strings.ToLower(constraint.noun), tag, quoteKeys(validatedTag.AllowedValues))),
+						tags.Range,
+					)
+					if err != nil {
+						return err
 					}
-					break
 				}
+				break
 			}
 		}
-		return nil
-	})
-	if err != nil {
-		return err
 	}
 
 	return nil
 }
+
+// Returns the tags of the module inputs the tags refer to, e.g. var.tags in merge(var.tags, { Name = "main" })
+func moduleInputTags(runner tflint.Runner, inputs []string, tags *tagging.Tags) map[string]string {
+	values := map[string]string{}
+	if tags.Expr == nil {
+		return values
+	}
+
+	for _, traversal := range tags.Expr.Variables() {
+		if traversal.RootName() != "var" || len(traversal) < 2 {
+			continue
+		}
+		attribute, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok || !slices.Contains(inputs, attribute.Name) {
+			continue
+		}
+
+		reference := &hclsyntax.ScopeTraversalExpr{
+			Traversal: traversal[:2],
+			SrcRange:  hcl.RangeBetween(traversal[0].SourceRange(), traversal[1].SourceRange()),
+		}
+		input := map[string]string{}
+		if err := runner.EvaluateExpr(reference, &input, nil); err == nil {
+			maps.Copy(values, input)
+		}
+	}
+	return values
+}
@@ -1,10 +1,17 @@
 package rules
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/0north/tflint-ruleset-0north-plugin/tagging"
+	"github.com/0north/tflint-ruleset-0north-plugin/utils"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/terraform-linters/tflint-plugin-sdk/helper"
+	"github.com/terraform-linters/tflint-plugin-sdk/terraform/addrs"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
 )
 
 func Test_ValidateTagsRule(t *testing.T) {
@@ -305,13 +312,983 @@ func Test_ValidateTagsRule(t *testing.T) {
 			}`,
 			Expected: helper.Issues{},
 		},
+		{
+			Name: "Fails_ForResource_WithConditionalTagNotMatching",
+			Content: `
+			resource "azurerm_storage_account" "scratch" {
+				tags = {
+					temporary  = "true"
+					expires-on = "soon"
+				}
+			}
+
+			resource "azurerm_storage_account" "tmp" {
+				tags = {
+					temporary  = "true"
+					expires-on = "2026-12-31"
+				}
+			}
+
+			resource "azurerm_storage_account" "data" {
+				tags = {
+					expires-on = "never"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags    = []
+
+				condition "temporary-expiry" {
+					if_tag       = "temporary"
+					if_matches   = "true"
+					then_tag     = "expires-on"
+					then_matches = "[0-9]{4}-[0-9]{2}-[0-9]{2}"
+				}
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag value \"soon\" of \"expires-on\" does not match \"[0-9]{4}-[0-9]{2}-[0-9]{2}\", which is required as \"temporary\" is \"true\"",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 6},
+						End:      hcl.Pos{Line: 5, Column: 25},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForResource_WithPolicyNotSatisfied",
+			Content: `
+			resource "azurerm_storage_account" "prod" {
+				tags = {
+					team = "platform-engineering"
+					env  = "prod"
+				}
+			}
+
+			resource "azurerm_storage_account" "oncall" {
+				tags = {
+					team   = "platform-engineering"
+					env    = "prod"
+					oncall = "platform-pager"
+				}
+			}
+
+			resource "azurerm_storage_account" "dev" {
+				tags = {
+					team = "cloud-crew"
+					env  = "dev"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags    = []
+
+				policy_variables = {
+					teams = ["platform-engineering", "voyage-optimization"]
+				}
+
+				policy "prod-oncall" {
+					condition = "lookup(tags, \"env\", \"\") != \"prod\" || can(tags.oncall)"
+					message   = "Production resources need an oncall tag."
+				}
+
+				policy "known-team" {
+					condition = "contains(teams, lookup(tags, \"team\", \"\"))"
+					message   = "The team is not a known team."
+					severity  = "warning"
+				}
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Production resources need an oncall tag.",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 12},
+						End:      hcl.Pos{Line: 6, Column: 6},
+					},
+				},
+				{
+					Rule:    utils.WithSeverity(NewValidateTagsRule(), tflint.WARNING),
+					Message: "The team is not a known team.",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 18, Column: 12},
+						End:      hcl.Pos{Line: 21, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForResource_WithInvalidTeamName_ButExcludedUntilLater",
+			Content: `
+			resource "aws_instance" "ec2_instance" {
+				region = "eu-west-1"
+				tags = {
+					team = "cloud-crew"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+				exclude = [
+					{
+						resource = "aws_instance"
+						reason   = "Renamed with the team reorganization"
+						until    = "2999-12-31"
+					}
+				]
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForProvider_WithInvalidCharacterInTagKey",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = {
+						"cost#center" = "1234",
+					}
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag key \"cost#center\" contains invalid character \"#\" at position 5 (allowed are letters, numbers, spaces and _ . : / = + - @)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 6},
+						End:      hcl.Pos{Line: 7, Column: 7},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForResource_WithTooLongTagKeyAndReservedPrefix",
+			Content: `
+			resource "aws_s3_bucket" "bucket" {
+				tags = {
+					"AWS:` + strings.Repeat("a", 125) + `" = "x"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag key \"AWS:" + strings.Repeat("a", 125) + "\" is 129 characters long, exceeding the maximum of 128",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 5},
+						End:      hcl.Pos{Line: 5, Column: 6},
+					},
+				},
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag key \"AWS:" + strings.Repeat("a", 125) + "\" uses the reserved prefix \"aws:\"",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 5},
+						End:      hcl.Pos{Line: 5, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForEC2Resource_WithAnyCharacterInTagValue",
+			Content: `
+			resource "aws_instance" "ec2_instance" {
+				tags = {
+					description = "runs #1 & #2"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForResource_WithTagValueExceedingConfiguredConstraint",
+			Content: `
+			resource "aws_s3_bucket" "bucket" {
+				tags = {
+					description = "a bucket for logs"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+				constraint "s3" {
+					resources = ["aws_s3_*"]
+					max_value_length = 10
+					allowed_characters = "a-z"
+				}
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag value for tag \"description\" is 17 characters long, exceeding the maximum of 10",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 5},
+						End:      hcl.Pos{Line: 5, Column: 6},
+					},
+				},
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag value \"a bucket for logs\" for tag \"description\" contains invalid character \" \" at position 2 (allowed are [a-z])",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 5},
+						End:      hcl.Pos{Line: 5, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForGoogleProvider_WithInvalidTeamName",
+			Content: `
+			provider "google" {
+				project = "zeronorth"
+				default_labels = {
+					team = "cloud-crew"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Label value \"cloud-crew\" is not allowed for label \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 4, Column: 5},
+						End:      hcl.Pos{Line: 6, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForGoogleResource_WithUppercaseLabel",
+			Content: `
+			resource "google_storage_bucket" "bucket" {
+				name = "logs"
+				labels = {
+					Team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Label key \"Team\" must start with a lowercase letter",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 4, Column: 5},
+						End:      hcl.Pos{Line: 6, Column: 6},
+					},
+				},
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Label key \"Team\" contains invalid character \"T\" at position 1 (allowed are lowercase letters, numbers, underscores and dashes)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 4, Column: 5},
+						End:      hcl.Pos{Line: 6, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForGoogleResource_WithValidLabels",
+			Content: `
+			resource "google_storage_bucket" "bucket" {
+				name = "logs"
+				labels = {
+					team        = "platform-engineering"
+					cost_center = "1234"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForAzurermResource_WithInvalidTeamNameAndTagKey",
+			Content: `
+			resource "azurerm_resource_group" "group" {
+				name     = "platform"
+				location = "West Europe"
+				tags = {
+					team           = "cloud-crew"
+					"cost&center"  = "1234"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag key \"cost&center\" contains invalid character \"&\" at position 5 (allowed are all characters except < > % & \\ ? /)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 5},
+						End:      hcl.Pos{Line: 8, Column: 6},
+					},
+				},
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag value \"cloud-crew\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 5},
+						End:      hcl.Pos{Line: 8, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForAzurermTrafficManager_WithSpaceInTagKey",
+			Content: `
+			resource "azurerm_traffic_manager_profile" "profile" {
+				name = "frontend"
+				tags = {
+					"cost center" = "1234"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag key \"cost center\" contains invalid character \" \" at position 5 (allowed are all characters except < > % & \\ ? / # : and spaces)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 4, Column: 5},
+						End:      hcl.Pos{Line: 6, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForAwsccResource_WithInvalidTeamName",
+			Content: `
+			resource "awscc_s3_bucket" "bucket" {
+				bucket_name = "logs"
+				tags = [
+					{
+						key   = "team"
+						value = "finance"
+					}
+				]
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag value \"finance\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 4, Column: 5},
+						End:      hcl.Pos{Line: 9, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForAutoscalingGroup_WithInvalidTeamNameInTagBlock",
+			Content: `
+			resource "aws_autoscaling_group" "asg" {
+				tag {
+					key                 = "team"
+					value               = "finance"
+					propagate_at_launch = true
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag value \"finance\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 5},
+						End:      hcl.Pos{Line: 3, Column: 8},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForAutoscalingGroup_WithInvalidTeamNameInDynamicTagBlock",
+			Content: `
+			resource "aws_autoscaling_group" "asg" {
+				dynamic "tag" {
+					for_each = {
+						team = "finance"
+					}
+
+					content {
+						key                 = tag.key
+						value               = tag.value
+						propagate_at_launch = true
+					}
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag value \"finance\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 5},
+						End:      hcl.Pos{Line: 3, Column: 18},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForAutoscalingGroup_WithDynamicTagBlockUsingCustomIterator",
+			Content: `
+			resource "aws_autoscaling_group" "asg" {
+				dynamic "tag" {
+					for_each = [
+						{ key = "team", value = "platform-engineering" },
+					]
+					iterator = item
+
+					content {
+						key                 = item.value.key
+						value               = item.value.value
+						propagate_at_launch = true
+					}
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForInstance_WithInvalidTeamNameInVolumeTags",
+			Content: `
+			resource "aws_instance" "ec2_instance" {
+				volume_tags = {
+					team = "finance"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag value \"finance\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 5},
+						End:      hcl.Pos{Line: 5, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForKubernetesResource_WithInvalidTeamNameAndLabelKey",
+			Content: `
+			resource "kubernetes_deployment_v1" "app" {
+				metadata {
+					name = "app"
+					labels = {
+						"example.com/team"   = "finance"
+						"Example.com/system" = "-billing"
+					}
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+				kubernetes_label_keys = {
+					team = "example.com/team"
+				}
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Label key \"Example.com/system\" has an invalid prefix \"Example.com\", it must be a DNS-1123 subdomain of at most 253 lowercase letters, numbers, dashes and dots, starting and ending with a letter or number",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 6},
+						End:      hcl.Pos{Line: 8, Column: 7},
+					},
+				},
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Label value \"-billing\" for label \"Example.com/system\" must start and end with a letter or number",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 6},
+						End:      hcl.Pos{Line: 8, Column: 7},
+					},
+				},
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Label value \"finance\" is not allowed for label \"example.com/team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 6},
+						End:      hcl.Pos{Line: 8, Column: 7},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForModuleCall_WithInvalidTeamName",
+			Content: `
+			module "storage" {
+				source = "./modules/storage"
+				tags = {
+					team = "finance"
+				}
+			}
+
+			module "logging" {
+				source = "./modules/logging"
+				tags   = var.tags
+			}
+
+			variable "tags" {
+				type    = map(string)
+				default = {
+					team = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag value \"finance\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 4, Column: 5},
+						End:      hcl.Pos{Line: 6, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForModuleCall_WithInvalidTagKey_FromConfiguredInput",
+			Content: `
+			module "storage" {
+				source      = "./modules/storage"
+				providers   = { aws = aws }
+				tags        = {}
+				common_tags = {
+					"aws:team" = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled       = true
+				tags          = []
+				module_inputs = ["common_tags"]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Tag key \"aws:team\" uses the reserved prefix \"aws:\"",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 6, Column: 5},
+						End:      hcl.Pos{Line: 8, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForModuleCall_WithReservedAWSPrefix_WithoutProviders",
+			Content: `
+			module "storage" {
+				source = "./modules/storage"
+				tags = {
+					"aws:team" = "platform-engineering"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags    = []
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForModuleCall_WithInvalidLabelValue_PassingGoogle",
+			Content: `
+			module "storage" {
+				source    = "./modules/storage"
+				providers = { google = google.europe }
+				tags = {
+					team = "Platform"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags    = []
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "Label value \"Platform\" for label \"team\" contains invalid character \"P\" at position 1 (allowed are lowercase letters, numbers, underscores and dashes)",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 5},
+						End:      hcl.Pos{Line: 7, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForForEachResource_WithInvalidTeamNameForOneInstance",
+			Content: `
+			resource "aws_s3_bucket" "b" {
+				for_each = {
+					data = "platform-engineering"
+					logs = "finance"
+				}
+				bucket = each.key
+				tags = merge(var.common_tags, {
+					team = each.value
+				})
+			}
+
+			variable "common_tags" {
+				type    = map(string)
+				default = {
+					owner = "platform"
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "aws_s3_bucket.b[\"logs\"]: Tag value \"finance\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 8, Column: 5},
+						End:      hcl.Pos{Line: 10, Column: 7},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForCountResource_WithInvalidTeamNameForOneInstance",
+			Content: `
+			resource "aws_s3_bucket" "b" {
+				count = 2
+				tags = {
+					team = var.teams[count.index]
+				}
+			}
+
+			variable "teams" {
+				type    = list(string)
+				default = ["voyage-optimization", "finance"]
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "aws_s3_bucket.b[1]: Tag value \"finance\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 4, Column: 5},
+						End:      hcl.Pos{Line: 6, Column: 6},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForForEachResource_WithUnknownForEach",
+			Content: `
+			resource "aws_s3_bucket" "b" {
+				for_each = aws_s3_bucket.other
+				tags = {
+					team = each.value.tags["team"]
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Succeeds_ForResource_WithUnknownTags_Ignored",
+			Content: `
+			resource "aws_s3_bucket" "b" {
+				tags = aws_s3_bucket.other.tags
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags	= []
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForResource_WithUnknownTags_AsNotice",
+			Content: `
+			resource "aws_s3_bucket" "b" {
+				tags = merge(aws_s3_bucket.other.tags, {
+					team = "platform-engineering"
+				})
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled    = true
+				tags	   = []
+				on_unknown = "notice"
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    utils.WithSeverity(NewValidateTagsRule(), tflint.NOTICE),
+					Message: "The tags of aws_s3_bucket.b could not be verified, as merge(aws_s3_bucket.other.tags, { team = \"platform-engineering\" }) could not be evaluated",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 5},
+						End:      hcl.Pos{Line: 5, Column: 7},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForProvider_WithUnknownDefaultTags_AsError",
+			Content: `
+			provider "aws" {
+				region = "eu-west-1"
+				default_tags {
+					tags = local.tags
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled    = true
+				tags	   = []
+				on_unknown = "error"
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    utils.WithSeverity(NewValidateTagsRule(), tflint.ERROR),
+					Message: "The default_tags.tags of the aws provider could not be verified, as local.tags could not be evaluated",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 5, Column: 6},
+						End:      hcl.Pos{Line: 5, Column: 23},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForAutoscalingGroup_WithUnexpandableDynamicTags_AsNotice",
+			Content: `
+			resource "aws_autoscaling_group" "asg" {
+				dynamic "tag" {
+					for_each = aws_s3_bucket.other.tags
+					content {
+						key                 = tag.key
+						value               = tag.value
+						propagate_at_launch = true
+					}
+				}
+			}`,
+			Config: `
+			rule "validate_tags" {
+				enabled    = true
+				tags	   = []
+				on_unknown = "notice"
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    utils.WithSeverity(NewValidateTagsRule(), tflint.NOTICE),
+					Message: "The tag blocks of aws_autoscaling_group.asg could not be verified, as the dynamic \"tag\" blocks could not be expanded",
+					Range: hcl.Range{
+						Filename: "resource.tf",
+						Start:    hcl.Pos{Line: 3, Column: 5},
+						End:      hcl.Pos{Line: 3, Column: 18},
+					},
+				},
+			},
+		},
 	}
 
 	rule := NewValidateTagsRule()
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
-			runner := helper.TestRunner(t, map[string]string{"resource.tf": test.Content, ".tflint.hcl": test.Config})
+			runner := newTestRunner(t, map[string]string{"resource.tf": test.Content, ".tflint.hcl": test.Config})
 
 			if err := rule.Check(runner); err != nil {
 				t.Fatalf("Unexpected error occurred: %s", err)
@@ -321,3 +1298,451 @@ func Test_ValidateTagsRule(t *testing.T) {
 		})
 	}
 }
+
+func Test_ValidateTagsRule_CalledModule(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Path     addrs.Module
+		Module   string
+		Expected helper.Issues
+	}{
+		{
+			Name: "Fails_ForNestedModuleResource_WithInvalidTeamNameFromModuleInput",
+			Path: addrs.Module{"network", "subnets"},
+			Module: `
+			variable "team" {
+				type    = string
+				default = "finance"
+			}
+
+			resource "aws_subnet" "this" {
+				vpc_id = "vpc-12345678"
+				tags = {
+					team = var.team
+				}
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "module.network.module.subnets.aws_subnet.this: Tag value \"finance\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "main.tf",
+						Start:    hcl.Pos{Line: 1, Column: 1},
+						End:      hcl.Pos{Line: 1, Column: 17},
+					},
+				},
+			},
+		},
+		{
+			Name: "Succeeds_ForModuleResource_WithInvalidTeamNameFromTagsInput",
+			Path: addrs.Module{"storage"},
+			Module: `
+			variable "tags" {
+				type    = map(string)
+				default = {
+					team = "finance"
+				}
+			}
+
+			resource "aws_s3_bucket" "this" {
+				bucket = "logs"
+				tags = {
+					Name = "logs"
+					team = var.tags["team"]
+				}
+			}`,
+			Expected: helper.Issues{},
+		},
+		{
+			Name: "Fails_ForModuleResource_OverridingTagsInput_WithInvalidTeamName",
+			Path: addrs.Module{"storage"},
+			Module: `
+			variable "tags" {
+				type    = map(string)
+				default = {
+					owner = "platform"
+					team  = "platform-engineering"
+				}
+			}
+
+			resource "aws_s3_bucket" "this" {
+				bucket = "logs"
+				tags = {
+					owner = var.tags["owner"]
+					team  = "finance"
+				}
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "module.storage.aws_s3_bucket.this: Tag value \"finance\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "main.tf",
+						Start:    hcl.Pos{Line: 1, Column: 1},
+						End:      hcl.Pos{Line: 1, Column: 17},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForNestedModuleCall_WithInvalidTeamName",
+			Path: addrs.Module{"platform"},
+			Module: `
+			variable "team" {
+				type    = string
+				default = "finance"
+			}
+
+			module "storage" {
+				source = "./storage"
+				tags = {
+					team = var.team
+				}
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "module.platform.module.storage: Tag value \"finance\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "main.tf",
+						Start:    hcl.Pos{Line: 1, Column: 1},
+						End:      hcl.Pos{Line: 1, Column: 18},
+					},
+				},
+			},
+		},
+		{
+			Name: "Fails_ForModuleForEachResource_WithInvalidTeamNameForOneInstance",
+			Path: addrs.Module{"buckets"},
+			Module: `
+			variable "buckets" {
+				type = map(object({
+					team = string
+				}))
+				default = {
+					data = {
+						team = "platform-engineering"
+					}
+					logs = {
+						team = "finance"
+					}
+				}
+			}
+
+			resource "aws_s3_bucket" "this" {
+				for_each = var.buckets
+				bucket   = each.key
+				tags = {
+					team = each.value.team
+				}
+			}`,
+			Expected: helper.Issues{
+				{
+					Rule:    NewValidateTagsRule(),
+					Message: "module.buckets.aws_s3_bucket.this[\"logs\"]: Tag value \"finance\" is not allowed for tag \"team\" (valid values are \"platform-engineering\", \"voyage-optimization\")",
+					Range: hcl.Range{
+						Filename: "main.tf",
+						Start:    hcl.Pos{Line: 1, Column: 1},
+						End:      hcl.Pos{Line: 1, Column: 17},
+					},
+				},
+			},
+		},
+	}
+
+	rule := NewValidateTagsRule()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			issues := checkCalledModule(t, rule, test.Path, rootModule(test.Path[0]), map[string]string{
+				"module.tf": test.Module,
+				".tflint.hcl": `
+			rule "validate_tags" {
+				enabled = true
+				tags	= [
+					{
+						tag = "team",
+						allowed_values = ["platform-engineering", "voyage-optimization"]
+					}
+				]
+			}`,
+			})
+
+			helper.AssertIssues(t, test.Expected, issues)
+		})
+	}
+}
+
+func Test_ValidateTagsRule_InvalidPolicies(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Config   string
+		Expected string
+	}{
+		{
+			Name: "UnknownVariable",
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags    = []
+
+				policy "known-team" {
+					condition = "contains(teams, tags.team)"
+					message   = "The team is not a known team."
+				}
+			}`,
+			Expected: "invalid condition in policy \"known-team\": unknown variable \"teams\"",
+		},
+		{
+			Name: "NotBool",
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags    = []
+
+				policy "team" {
+					condition = "resource.name"
+					message   = "The resource has no team."
+				}
+			}`,
+			Expected: "invalid condition in policy \"team\": it should be a bool, got string",
+		},
+		{
+			Name: "UnknownFunction",
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags    = []
+
+				policy "team" {
+					condition = "has(tags.team)"
+					message   = "The resource has no team."
+				}
+			}`,
+			Expected: "invalid condition in policy \"team\"",
+		},
+		{
+			Name: "ReservedVariable",
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags    = []
+
+				policy_variables = {
+					tags = ["team"]
+				}
+			}`,
+			Expected: "policy_variables can't contain \"tags\"",
+		},
+		{
+			Name: "UnknownSeverity",
+			Config: `
+			rule "validate_tags" {
+				enabled = true
+				tags    = []
+
+				policy "team" {
+					condition = "can(tags.team)"
+					message   = "The resource has no team."
+					severity  = "fatal"
+				}
+			}`,
+			Expected: "unknown severity \"fatal\" in policy \"team\"",
+		},
+	}
+
+	rule := NewValidateTagsRule()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{"resource.tf": "", ".tflint.hcl": test.Config})
+
+			err := rule.Check(runner)
+			if err == nil || !strings.Contains(err.Error(), test.Expected) {
+				t.Fatalf("Expected an error containing %q, got %v", test.Expected, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateTagsRule_PolicyEvaluationErrors(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Condition string
+		Expected  string
+	}{
+		{
+			Name:      "MissingTag",
+			Condition: "tags.oncall != \"\"",
+			Expected:  "The condition of policy \"oncall\" could not be evaluated: condition:1,5-12: Missing map element; This map does not have an element with the key \"oncall\".",
+		},
+		{
+			Name:      "Null",
+			Condition: "lookup(tags, \"env\", \"\") == \"prod\" ? true : null",
+			Expected:  "The condition of policy \"oncall\" could not be evaluated: it should be a bool, got null",
+		},
+	}
+
+	rule := NewValidateTagsRule()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			runner := helper.TestRunner(t, map[string]string{
+				"resource.tf": `
+			resource "aws_s3_bucket" "b" {
+				tags = {
+					env = "dev"
+				}
+			}`,
+				".tflint.hcl": fmt.Sprintf(`
+			rule "validate_tags" {
+				enabled = true
+				tags    = []
+
+				policy "oncall" {
+					condition = %q
+					message   = "The resource needs an oncall tag."
+				}
+
+				policy "team" {
+					condition = "has(tags, \"team\")"
+					message   = "The resource needs a team tag."
+				}
+			}`, test.Condition),
+			})
+
+			// The policy that can't be evaluated is reported and the other policies are still verified
+			if err := rule.Check(runner); err != nil {
+				t.Fatalf("Unexpected error occurred: %s", err)
+			}
+			issueRange := hcl.Range{
+				Filename: "resource.tf",
+				Start:    hcl.Pos{Line: 3, Column: 12},
+				End:      hcl.Pos{Line: 5, Column: 6},
+			}
+			helper.AssertIssues(t, helper.Issues{
+				{Rule: NewValidateTagsRule(), Message: test.Expected, Range: issueRange},
+				{Rule: NewValidateTagsRule(), Message: "The resource needs a team tag.", Range: issueRange},
+			}, runner.Issues)
+		})
+	}
+}
+
+// Benchmarks run on a synthetic module with resources of every provider, most of them taggable
+const benchmarkResources = 1000
+
+func Benchmark_ValidateTagsRule(b *testing.B) {
+	runner := newBenchmarkRunner(b, benchmarkResources)
+	rule := NewValidateTagsRule()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runner.Issues = helper.Issues{}
+		if err := rule.Check(runner); err != nil {
+			b.Fatalf("Unexpected error occurred: %s", err)
+		}
+	}
+	b.ReportMetric(float64(runner.queries)/float64(b.N), "queries/op")
+}
+
+// Compares fetching the resources of every taggable type one by one, as every rule used to, with fetching them in a single query
+func Benchmark_ResourceCollection(b *testing.B) {
+	b.Run("PerType", func(b *testing.B) {
+		runner := newBenchmarkRunner(b, benchmarkResources)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, provider := range tagging.Providers {
+				for _, resourceType := range provider.Resources() {
+					schema := tagging.Schema(provider.ResourceTags(resourceType), "provider", "count", "for_each")
+					if _, err := runner.GetResourceContent(resourceType, schema, nil); err != nil {
+						b.Fatalf("Unexpected error occurred: %s", err)
+					}
+				}
+			}
+		}
+		b.ReportMetric(float64(runner.queries)/float64(b.N), "queries/op")
+	})
+
+	b.Run("SinglePass", func(b *testing.B) {
+		runner := newBenchmarkRunner(b, benchmarkResources)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, provider := range tagging.Providers {
+				if _, err := tagging.GetResourceBlocks(runner, provider, nil, tflint.ExpandModeExpand); err != nil {
+					b.Fatalf("Unexpected error occurred: %s", err)
+				}
+			}
+		}
+		b.ReportMetric(float64(runner.queries)/float64(b.N), "queries/op")
+	})
+}
+
+// Returns a runner for a module with the given number of resources
+func newBenchmarkRunner(b *testing.B, resources int) *countingRunner {
+	templates := []string{
+		`resource "aws_s3_bucket" "b%d" {
+  bucket = "bucket"
+  tags = {
+    team = "platform-engineering"
+  }
+}`,
+		`resource "aws_instance" "i%d" {
+  ami = "ami-12345678"
+  tags = {
+    team = "platform-engineering"
+  }
+  root_block_device {
+    tags = {
+      team = "platform-engineering"
+    }
+  }
+}`,
+		`resource "aws_iam_role_policy_attachment" "a%d" {
+  role       = "role"
+  policy_arn = "arn"
+}`,
+		`resource "google_storage_bucket" "g%d" {
+  name = "bucket"
+  labels = {
+    team = "platform-engineering"
+  }
+}`,
+		`resource "azurerm_resource_group" "r%d" {
+  name = "group"
+  tags = {
+    team = "platform-engineering"
+  }
+}`,
+		`resource "kubernetes_namespace" "n%d" {
+  metadata {
+    name = "namespace"
+    labels = {
+      team = "platform-engineering"
+    }
+  }
+}`,
+	}
+
+	content := `provider "aws" {
+  default_tags {
+    tags = {
+      team = "platform-engineering"
+    }
+  }
+}
+`
+	for i := 0; i < resources; i++ {
+		content += "\n" + fmt.Sprintf(templates[i%len(templates)], i) + "\n"
+	}
+
+	file, diags := hclparse.NewParser().ParseHCL([]byte(content), "resource.tf")
+	if diags.HasErrors() {
+		b.Fatal(diags)
+	}
+	runner := helper.NewLocalRunner(map[string]*hcl.File{}, helper.Issues{})
+	runner.AddLocalFile("resource.tf", file)
+
+	return &countingRunner{Runner: runner}
+}
@@ -0,0 +1,50 @@
+package tagging
+
+// AWS Cloud Control resource types with a top-level `tags` attribute holding a list of key/value objects.
+// Some awscc resources take a map instead, those are not listed here. The awscc provider has no provider level default tags.
+var awsccTagResources = []string{
+	"awscc_apigateway_rest_api",
+	"awscc_apprunner_service",
+	"awscc_athena_work_group",
+	"awscc_backup_backup_vault",
+	"awscc_cloudfront_distribution",
+	"awscc_cloudtrail_trail",
+	"awscc_cloudwatch_alarm",
+	"awscc_codebuild_project",
+	"awscc_dynamodb_table",
+	"awscc_ec2_eip",
+	"awscc_ec2_instance",
+	"awscc_ec2_internet_gateway",
+	"awscc_ec2_launch_template",
+	"awscc_ec2_nat_gateway",
+	"awscc_ec2_route_table",
+	"awscc_ec2_security_group",
+	"awscc_ec2_subnet",
+	"awscc_ec2_volume",
+	"awscc_ec2_vpc",
+	"awscc_ecr_repository",
+	"awscc_ecs_cluster",
+	"awscc_ecs_service",
+	"awscc_ecs_task_definition",
+	"awscc_efs_file_system",
+	"awscc_eks_cluster",
+	"awscc_elasticloadbalancingv2_load_balancer",
+	"awscc_elasticloadbalancingv2_target_group",
+	"awscc_events_event_bus",
+	"awscc_iam_role",
+	"awscc_iam_user",
+	"awscc_kinesis_stream",
+	"awscc_kms_key",
+	"awscc_lambda_function",
+	"awscc_logs_log_group",
+	"awscc_rds_db_cluster",
+	"awscc_rds_db_instance",
+	"awscc_route53_hosted_zone",
+	"awscc_s3_bucket",
+	"awscc_secretsmanager_secret",
+	"awscc_sns_topic",
+	"awscc_sqs_queue",
+	"awscc_ssm_parameter",
+	"awscc_stepfunctions_state_machine",
+	"awscc_wafv2_web_acl",
+}
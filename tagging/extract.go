@@ -0,0 +1,856 @@
+package tagging
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0north/tflint-ruleset-0north-plugin/cache"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terraform-linters/tflint-plugin-sdk/hclext"
+	"github.com/terraform-linters/tflint-plugin-sdk/terraform/addrs"
+	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// ExceptionKey is the tag key an exception to the tag rules can be written under. It only marks the exception and is not one
+// of the tags the rules check, so it is left out of the values of the tags.
+const ExceptionKey = "0north:tag-exception"
+
+// Tags are the tags found at one location of a provider or resource block
+type Tags struct {
+	Location Location
+	// Values holds the evaluated tags, it is nil if they could not be evaluated
+	Values map[string]string
+	// Range is the range of the attribute, or of the first block for BlockFormat
+	Range hcl.Range
+	// ValueRange is the range of the expression holding the tags, or of the first block for BlockFormat
+	ValueRange hcl.Range
+	// Expr is the expression holding the tags, it is nil for BlockFormat
+	Expr hcl.Expression
+	// Type is the value of the TypeAttribute of the location, e.g. instance, it is empty if there is none or it could not be evaluated
+	Type string
+	// Exception is the value of the ExceptionKey tag, it is empty if there is none
+	Exception string
+
+	keyRanges map[string]hcl.Range
+}
+
+// Known returns whether the tags could be evaluated
+func (t *Tags) Known() bool {
+	return t.Values != nil
+}
+
+// KeyRange returns the range where the given key is set if it can be pinned down, or the range of the tags otherwise
+func (t *Tags) KeyRange(key string) hcl.Range {
+	if keyRange, exists := t.keyRanges[key]; exists {
+		return keyRange
+	}
+	return t.Range
+}
+
+// ProviderConfig is a provider block together with its default tags
+type ProviderConfig struct {
+	Alias       string
+	Block       *hclext.Block
+	DefaultTags []*Tags
+}
+
+// Resource is a block of a taggable resource type together with its tags
+type Resource struct {
+	Type          string
+	Name          string
+	Block         *hclext.Block
+	ProviderAlias string
+	// Key is the index or key of the instance for resources using count or for_each, it is cty.NilVal for other resources
+	// and for resources whose instances can't be known statically
+	Key cty.Value
+	// Tags holds the tags of every location present on the resource
+	Tags []*Tags
+	// InheritsDefaultTags is whether the provider default tags apply to the resource, which isn't the case for types
+	// whose tags are all standalone such as autoscaling groups
+	InheritsDefaultTags bool
+}
+
+// Address returns the address of the resource, e.g. aws_instance.web, or of the instance, e.g. aws_s3_bucket.b["logs"]
+func (r *Resource) Address() string {
+	address := fmt.Sprintf("%s.%s", r.Type, r.Name)
+	switch {
+	case r.Key == cty.NilVal:
+		return address
+	case r.Key.Type() == cty.String:
+		return fmt.Sprintf("%s[%q]", address, r.Key.AsString())
+	default:
+		return fmt.Sprintf("%s[%s]", address, r.Key.AsBigFloat().Text('f', -1))
+	}
+}
+
+// IsInstance returns whether the resource is one instance of a resource using count or for_each
+func (r *Resource) IsInstance() bool {
+	return r.Key != cty.NilVal
+}
+
+// OwnTags returns the tags of the resource itself, leaving out standalone tags such as those of its volumes.
+// Resources that don't inherit default tags, such as autoscaling groups, only have standalone tags and they are their own.
+func (r *Resource) OwnTags() []*Tags {
+	own := []*Tags{}
+	for _, tags := range r.Tags {
+		if !tags.Location.Standalone || !r.InheritsDefaultTags {
+			own = append(own, tags)
+		}
+	}
+	return own
+}
+
+// Known returns whether the tags of the resource itself could be evaluated
+func (r *Resource) Known() bool {
+	for _, tags := range r.OwnTags() {
+		if !tags.Known() {
+			return false
+		}
+	}
+	return true
+}
+
+// Values returns the evaluated tags of the resource itself merged together
+func (r *Resource) Values() map[string]string {
+	values := map[string]string{}
+	for _, tags := range r.OwnTags() {
+		for key, value := range tags.Values {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// ModuleCall is a module block together with the tags passed to its inputs
+type ModuleCall struct {
+	Name  string
+	Block *hclext.Block
+	// Tags holds the tags of every input present on the module call
+	Tags []*Tags
+	// Providers holds the names of the providers the providers argument of the call passes, e.g. aws, sorted.
+	// It is empty if the call passes the default configurations implicitly.
+	Providers []string
+}
+
+// GetProviderConfigs returns every configuration of the provider together with its default tags
+func GetProviderConfigs(runner tflint.Runner, p Provider) ([]*ProviderConfig, error) {
+	return cache.Get(runner, "tagging.provider-configs."+p.Name(), func() ([]*ProviderConfig, error) {
+		return getProviderConfigs(runner, p)
+	})
+}
+
+// Evaluates the default tags of every configuration of the provider the module of the runner uses
+func getProviderConfigs(runner tflint.Runner, p Provider) ([]*ProviderConfig, error) {
+	path, err := runner.GetModulePath()
+	if err != nil {
+		return nil, err
+	}
+	if path.IsRoot() {
+		return readProviderConfigs(runner, p)
+	}
+	return getInheritedProviderConfigs(runner, p, path)
+}
+
+// Evaluates the default tags of every configuration of the provider in the module of the runner
+func readProviderConfigs(runner tflint.Runner, p Provider) ([]*ProviderConfig, error) {
+	locations := []Location{}
+	if p.DefaultTags() != nil {
+		locations = append(locations, *p.DefaultTags())
+	}
+
+	providers, err := runner.GetProviderContent(p.Name(), Schema(locations, "alias"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := []*ProviderConfig{}
+	for _, block := range providers.Blocks {
+		config := &ProviderConfig{Block: block, DefaultTags: []*Tags{}}
+		if attribute, exists := block.Body.Attributes["alias"]; exists {
+			err := runner.EvaluateExpr(attribute.Expr, &config.Alias, nil)
+			if err != nil {
+				continue
+			}
+		}
+		for _, location := range locations {
+			config.DefaultTags = append(config.DefaultTags, Extract(runner, block, location)...)
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// Returns the configurations of the provider a called module uses, by their alias in the module.
+// Called modules inherit the configurations of the root module through the providers argument of the module call, or
+// only the default configuration without one. Only the root module and the called module can be read, so modules called
+// from other modules are assumed to be passed the default configuration of the module calling them implicitly.
+// Configurations in the called module itself take precedence.
+func getInheritedProviderConfigs(runner tflint.Runner, p Provider, path addrs.Module) ([]*ProviderConfig, error) {
+	configs, err := readProviderConfigs(runner, p)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &rootRunner{Runner: runner}
+	rootConfigs, err := readProviderConfigs(root, p)
+	if err != nil {
+		return nil, err
+	}
+	passed, err := passedProviderConfigs(root, p, path[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(path) > 1 {
+		defaultAlias, exists := passed[""]
+		passed = map[string]string{}
+		if exists {
+			passed[""] = defaultAlias
+		}
+	}
+
+	aliases := maps.Keys(passed)
+	slices.Sort(aliases)
+	for _, alias := range aliases {
+		if slices.ContainsFunc(configs, func(config *ProviderConfig) bool { return config.Alias == alias }) {
+			continue
+		}
+		for _, rootConfig := range rootConfigs {
+			if rootConfig.Alias == passed[alias] {
+				inherited := *rootConfig
+				inherited.Alias = alias
+				configs = append(configs, &inherited)
+			}
+		}
+	}
+
+	return configs, nil
+}
+
+// Returns the alias in the root module of every configuration of the provider the module call passes, by its alias in the called module.
+// A call without a providers argument passes the default configuration.
+func passedProviderConfigs(root tflint.Runner, p Provider, name string) (map[string]string, error) {
+	content, err := root.GetModuleContent(&hclext.BodySchema{
+		Blocks: []hclext.BlockSchema{
+			{
+				Type:       "module",
+				LabelNames: []string{"name"},
+				Body:       &hclext.BodySchema{Attributes: []hclext.AttributeSchema{{Name: "providers"}}},
+			},
+		},
+	}, &tflint.GetModuleContentOption{ExpandMode: tflint.ExpandModeNone})
+	if err != nil {
+		return nil, err
+	}
+
+	passed := map[string]string{"": ""}
+	for _, block := range content.Blocks {
+		attribute, exists := block.Body.Attributes["providers"]
+		if block.Labels[0] != name || !exists {
+			continue
+		}
+
+		// e.g. providers = { aws = aws.west, aws.replica = aws.east }
+		passed = map[string]string{}
+		pairs, diags := hcl.ExprMap(attribute.Expr)
+		if diags.HasErrors() {
+			break
+		}
+		for _, pair := range pairs {
+			localName, localAlias, ok := providerReference(pair.Key)
+			if !ok || localName != p.Name() {
+				continue
+			}
+			if rootName, rootAlias, ok := providerReference(pair.Value); ok && rootName == p.Name() {
+				passed[localAlias] = rootAlias
+			}
+		}
+	}
+	return passed, nil
+}
+
+// rootRunner is a tflint.Runner for the root module when checking a called module, reading and evaluating in its context
+type rootRunner struct {
+	tflint.Runner
+}
+
+// GetModuleContent gets a content of the root module
+func (r *rootRunner) GetModuleContent(schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (*hclext.BodyContent, error) {
+	return r.Runner.GetModuleContent(schema, rootModuleContentOption(opts))
+}
+
+// GetProviderContent gets a provider content of the root module
+func (r *rootRunner) GetProviderContent(name string, schema *hclext.BodySchema, opts *tflint.GetModuleContentOption) (*hclext.BodyContent, error) {
+	return r.Runner.GetProviderContent(name, schema, rootModuleContentOption(opts))
+}
+
+// EvaluateExpr evaluates an expression of the root module
+func (r *rootRunner) EvaluateExpr(expr hcl.Expression, ret interface{}, opts *tflint.EvaluateExprOption) error {
+	rootOpts := &tflint.EvaluateExprOption{ModuleCtx: tflint.RootModuleCtxType}
+	if opts != nil {
+		rootOpts.WantType = opts.WantType
+	}
+	return r.Runner.EvaluateExpr(expr, ret, rootOpts)
+}
+
+// Returns the options with the module context set to the root module
+func rootModuleContentOption(opts *tflint.GetModuleContentOption) *tflint.GetModuleContentOption {
+	rootOpts := &tflint.GetModuleContentOption{ModuleCtx: tflint.RootModuleCtxType}
+	if opts != nil {
+		rootOpts.ExpandMode = opts.ExpandMode
+		rootOpts.Hint = opts.Hint
+	}
+	return rootOpts
+}
+
+// GetResources returns every resource of the taggable types of the provider that are not excluded, together with their tags
+func GetResources(runner tflint.Runner, p Provider, exclude []string) ([]*Resource, error) {
+	resources, err := cache.Get(runner, "tagging.resources."+p.Name(), func() ([]*Resource, error) {
+		return getResources(runner, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip the resources whose type is excluded in the configuration
+	included := []*Resource{}
+	for _, resource := range resources {
+		if !slices.Contains(exclude, resource.Type) {
+			included = append(included, resource)
+		}
+	}
+	return included, nil
+}
+
+// Evaluates the tags of every resource of the taggable types of the provider
+func getResources(runner tflint.Runner, p Provider) ([]*Resource, error) {
+	blocks, err := GetResourceBlocks(runner, p, nil, tflint.ExpandModeExpand)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []*Resource{}
+	for start := 0; start < len(blocks); {
+		// TFLint expands resources using count or for_each into a block per instance, all with the range of the resource
+		end := start + 1
+		for end < len(blocks) && blocks[end].DefRange == blocks[start].DefRange {
+			end++
+		}
+		instances := blocks[start:end]
+		start = end
+
+		keys := instanceKeys(runner, instances)
+		for index, block := range instances {
+			key := cty.NilVal
+			if keys != nil {
+				key = keys[index]
+			}
+			resources = append(resources, newResource(runner, block, p.ResourceTags(block.Labels[0]), key))
+		}
+	}
+
+	return resources, nil
+}
+
+// Returns the keys of the instances TFLint expanded a resource into, in the order it expands them: the index for count,
+// or the key for for_each. It returns nil if the resource uses neither or the keys can't be matched to the instances,
+// e.g. when count or for_each is unknown and TFLint doesn't expand the resource.
+func instanceKeys(runner tflint.Runner, instances hclext.Blocks) []cty.Value {
+	keys := []cty.Value{}
+	if attribute, exists := instances[0].Body.Attributes["for_each"]; exists {
+		var forEach cty.Value
+		if err := runner.EvaluateExpr(attribute.Expr, &forEach, nil); err != nil || !forEach.IsWhollyKnown() || forEach.IsNull() || !forEach.CanIterateElements() {
+			return nil
+		}
+		// for_each takes a map, or a set of strings used both as keys and values
+		for iterator := forEach.ElementIterator(); iterator.Next(); {
+			key, _ := iterator.Element()
+			if key.IsNull() || key.Type() != cty.String {
+				return nil
+			}
+			keys = append(keys, key)
+		}
+	} else if attribute, exists := instances[0].Body.Attributes["count"]; exists {
+		var count int
+		if err := runner.EvaluateExpr(attribute.Expr, &count, nil); err != nil {
+			return nil
+		}
+		for index := 0; index < count; index++ {
+			keys = append(keys, cty.NumberIntVal(int64(index)))
+		}
+	}
+
+	if len(keys) == 0 || len(keys) != len(instances) {
+		return nil
+	}
+	return keys
+}
+
+// GetResourceBlocks returns the blocks of every resource of the taggable types of the provider that are not excluded.
+// Providers have hundreds of taggable types and every query is a round trip to TFLint, so the resources of every type
+// are fetched at once with the locations of all of them, then filtered by type.
+// With ExpandModeExpand, TFLint expands resources using count or for_each into a block per instance and dynamic tag
+// blocks into blocks, binding the references to each and count to their values. With ExpandModeNone, the blocks are
+// returned as written.
+func GetResourceBlocks(runner tflint.Runner, p Provider, exclude []string, mode tflint.ExpandMode) (hclext.Blocks, error) {
+	content, err := runner.GetModuleContent(&hclext.BodySchema{
+		Blocks: []hclext.BlockSchema{
+			{Type: "resource", LabelNames: []string{"type", "name"}, Body: resourceSchema},
+		},
+	}, &tflint.GetModuleContentOption{ExpandMode: mode})
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := hclext.Blocks{}
+	for _, block := range content.Blocks {
+		// Skip this resource if its type is excluded in the configuration
+		if !p.Supports(block.Labels[0]) || slices.Contains(exclude, block.Labels[0]) {
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
+
+// The schema of the resources of every provider, holding the tags of every taggable type
+var resourceSchema = func() *hclext.BodySchema {
+	locations := []Location{}
+	for _, p := range Providers {
+		for _, resourceType := range p.Resources() {
+			locations = append(locations, p.ResourceTags(resourceType)...)
+		}
+	}
+	return Schema(locations, "provider", "count", "for_each")
+}()
+
+// Returns the resource of the block with the tags at the locations, evaluated by the runner
+func newResource(runner tflint.Runner, block *hclext.Block, locations []Location, key cty.Value) *Resource {
+	resource := &Resource{
+		Type:          block.Labels[0],
+		Name:          block.Labels[1],
+		Block:         block,
+		ProviderAlias: providerAlias(block),
+		Key:           key,
+		Tags:          []*Tags{},
+	}
+	for _, location := range locations {
+		resource.Tags = append(resource.Tags, Extract(runner, block, location)...)
+		if !location.Standalone {
+			resource.InheritsDefaultTags = true
+		}
+	}
+	return resource
+}
+
+// GetModuleCalls returns every module call together with the tags passed to the given inputs, e.g. tags
+func GetModuleCalls(runner tflint.Runner, inputs []string) ([]*ModuleCall, error) {
+	return cache.Get(runner, "tagging.module-calls."+strings.Join(inputs, ","), func() ([]*ModuleCall, error) {
+		return getModuleCalls(runner, inputs)
+	})
+}
+
+// Evaluates the tags passed to the inputs of every module call.
+// Calls using count or for_each are read once rather than per instance, so tags referring to each or count are unknown.
+func getModuleCalls(runner tflint.Runner, inputs []string) ([]*ModuleCall, error) {
+	locations := []Location{}
+	for _, input := range inputs {
+		locations = append(locations, Location{Name: input, Format: MapFormat})
+	}
+
+	content, err := runner.GetModuleContent(&hclext.BodySchema{
+		Blocks: []hclext.BlockSchema{{Type: "module", LabelNames: []string{"name"}, Body: Schema(locations, "providers")}},
+	}, &tflint.GetModuleContentOption{ExpandMode: tflint.ExpandModeNone})
+	if err != nil {
+		return nil, err
+	}
+
+	calls := []*ModuleCall{}
+	for _, block := range content.Blocks {
+		call := &ModuleCall{Name: block.Labels[0], Block: block, Tags: []*Tags{}, Providers: []string{}}
+		for _, location := range locations {
+			call.Tags = append(call.Tags, Extract(runner, block, location)...)
+		}
+		if attribute, exists := block.Body.Attributes["providers"]; exists {
+			pairs, _ := hcl.ExprMap(attribute.Expr)
+			for _, pair := range pairs {
+				if name, _, ok := providerReference(pair.Key); ok && !slices.Contains(call.Providers, name) {
+					call.Providers = append(call.Providers, name)
+				}
+			}
+			slices.Sort(call.Providers)
+		}
+		calls = append(calls, call)
+	}
+
+	return calls, nil
+}
+
+// Schema returns the schema needed to extract the tags at the given locations, in addition to the given attributes
+func Schema(locations []Location, attributes ...string) *hclext.BodySchema {
+	schema := &hclext.BodySchema{}
+	for _, attribute := range attributes {
+		schema.Attributes = append(schema.Attributes, hclext.AttributeSchema{Name: attribute})
+	}
+
+	for _, location := range locations {
+		body := schema
+		for _, blockType := range location.Blocks {
+			index := slices.IndexFunc(body.Blocks, func(block hclext.BlockSchema) bool { return block.Type == blockType })
+			if index == -1 {
+				body.Blocks = append(body.Blocks, hclext.BlockSchema{Type: blockType, Body: &hclext.BodySchema{}})
+				index = len(body.Blocks) - 1
+			}
+			body = body.Blocks[index].Body
+		}
+
+		if location.TypeAttribute != "" && !hasAttribute(body, location.TypeAttribute) {
+			body.Attributes = append(body.Attributes, hclext.AttributeSchema{Name: location.TypeAttribute})
+		}
+
+		switch location.Format {
+		case BlockFormat:
+			if hasBlock(body, location.Name) {
+				continue
+			}
+			tagSchema := &hclext.BodySchema{
+				Attributes: []hclext.AttributeSchema{{Name: "key"}, {Name: "value"}},
+			}
+			body.Blocks = append(body.Blocks, hclext.BlockSchema{Type: location.Name, Body: tagSchema})
+			if !hasBlock(body, "dynamic") {
+				body.Blocks = append(body.Blocks, hclext.BlockSchema{
+					Type:       "dynamic",
+					LabelNames: []string{"name"},
+					Body: &hclext.BodySchema{
+						Attributes: []hclext.AttributeSchema{{Name: "for_each"}, {Name: "iterator"}},
+						Blocks:     []hclext.BlockSchema{{Type: "content", Body: tagSchema}},
+					},
+				})
+			}
+		default:
+			if !hasAttribute(body, location.Name) {
+				body.Attributes = append(body.Attributes, hclext.AttributeSchema{Name: location.Name})
+			}
+		}
+	}
+
+	return schema
+}
+
+// Returns whether the schema has the attribute
+func hasAttribute(schema *hclext.BodySchema, name string) bool {
+	return slices.ContainsFunc(schema.Attributes, func(attribute hclext.AttributeSchema) bool { return attribute.Name == name })
+}
+
+// Returns whether the schema has the block type
+func hasBlock(schema *hclext.BodySchema, blockType string) bool {
+	return slices.ContainsFunc(schema.Blocks, func(block hclext.BlockSchema) bool { return block.Type == blockType })
+}
+
+// Extract evaluates the tags at the location of the block. There is one result for every nested block on the path to the location that holds tags.
+func Extract(runner tflint.Runner, block *hclext.Block, location Location) []*Tags {
+	bodies := []*hclext.BodyContent{block.Body}
+	for _, blockType := range location.Blocks {
+		nested := []*hclext.BodyContent{}
+		for _, body := range bodies {
+			for _, block := range body.Blocks.OfType(blockType) {
+				nested = append(nested, block.Body)
+			}
+		}
+		bodies = nested
+	}
+
+	found := []*Tags{}
+	for _, body := range bodies {
+		var tags *Tags
+		switch location.Format {
+		case MapFormat:
+			tags = extractMap(runner, body, location)
+		case KeyValueListFormat:
+			tags = extractKeyValueList(runner, body, location)
+		case BlockFormat:
+			tags = extractBlocks(runner, body, location)
+		}
+		if tags == nil {
+			continue
+		}
+		if exception, exists := tags.Values[ExceptionKey]; exists {
+			tags.Exception = exception
+			delete(tags.Values, ExceptionKey)
+		}
+		if attribute, exists := body.Attributes[location.TypeAttribute]; exists {
+			var tagsType string
+			if err := runner.EvaluateExpr(attribute.Expr, &tagsType, nil); err == nil {
+				tags.Type = tagsType
+			}
+		}
+		found = append(found, tags)
+	}
+	return found
+}
+
+// Reads tags written as a map attribute
+func extractMap(runner tflint.Runner, body *hclext.BodyContent, location Location) *Tags {
+	attribute, exists := body.Attributes[location.Name]
+	if !exists {
+		return nil
+	}
+
+	tags := &Tags{
+		Location:   location,
+		Range:      attribute.Range,
+		ValueRange: attribute.Expr.Range(),
+		Expr:       attribute.Expr,
+		keyRanges:  map[string]hcl.Range{},
+	}
+
+	values := map[string]string{}
+	err := runner.EvaluateExpr(attribute.Expr, &values, nil)
+	if err != nil {
+		return tags
+	}
+	if values == nil {
+		values = map[string]string{}
+	}
+	tags.Values = values
+
+	if object, ok := attribute.Expr.(*hclsyntax.ObjectConsExpr); ok {
+		for _, item := range object.Items {
+			if key, ok := staticString(item.KeyExpr); ok {
+				tags.keyRanges[key] = hcl.RangeBetween(item.KeyExpr.Range(), item.ValueExpr.Range())
+			}
+		}
+	}
+
+	return tags
+}
+
+// Reads tags written as an attribute holding a list of key/value objects
+func extractKeyValueList(runner tflint.Runner, body *hclext.BodyContent, location Location) *Tags {
+	attribute, exists := body.Attributes[location.Name]
+	if !exists {
+		return nil
+	}
+
+	tags := &Tags{
+		Location:   location,
+		Range:      attribute.Range,
+		ValueRange: attribute.Expr.Range(),
+		Expr:       attribute.Expr,
+		keyRanges:  map[string]hcl.Range{},
+	}
+
+	var list cty.Value
+	err := runner.EvaluateExpr(attribute.Expr, &list, nil)
+	if err != nil || !list.IsWhollyKnown() || list.IsNull() || !list.CanIterateElements() {
+		return tags
+	}
+
+	values := map[string]string{}
+	for it := list.ElementIterator(); it.Next(); {
+		_, element := it.Element()
+		key, keyOk := stringAttribute(element, "key")
+		value, valueOk := stringAttribute(element, "value")
+		if !keyOk || !valueOk {
+			return tags
+		}
+		values[key] = value
+	}
+	tags.Values = values
+
+	if tuple, ok := attribute.Expr.(*hclsyntax.TupleConsExpr); ok {
+		for _, item := range tuple.Exprs {
+			object, ok := item.(*hclsyntax.ObjectConsExpr)
+			if !ok {
+				continue
+			}
+			for _, objectItem := range object.Items {
+				if name, ok := staticString(objectItem.KeyExpr); ok && name == "key" {
+					if key, ok := staticString(objectItem.ValueExpr); ok {
+						tags.keyRanges[key] = object.Range()
+					}
+				}
+			}
+		}
+	}
+
+	return tags
+}
+
+// Reads tags written as repeated blocks with key and value attributes, expanding `dynamic` blocks generating them
+func extractBlocks(runner tflint.Runner, body *hclext.BodyContent, location Location) *Tags {
+	blocks := body.Blocks.OfType(location.Name)
+	dynamicBlocks := []*hclext.Block{}
+	for _, block := range body.Blocks.OfType("dynamic") {
+		if block.Labels[0] == location.Name {
+			dynamicBlocks = append(dynamicBlocks, block)
+		}
+	}
+	if len(blocks) == 0 && len(dynamicBlocks) == 0 {
+		return nil
+	}
+
+	tags := &Tags{
+		Location:  location,
+		keyRanges: map[string]hcl.Range{},
+	}
+	if len(blocks) > 0 {
+		tags.Range = blocks[0].DefRange
+	} else {
+		tags.Range = dynamicBlocks[0].DefRange
+	}
+	tags.ValueRange = tags.Range
+
+	values := map[string]string{}
+	for _, block := range blocks {
+		keyAttribute, keyExists := block.Body.Attributes["key"]
+		valueAttribute, valueExists := block.Body.Attributes["value"]
+		if !keyExists || !valueExists {
+			return tags
+		}
+
+		var key, value string
+		if err := runner.EvaluateExpr(keyAttribute.Expr, &key, nil); err != nil {
+			return tags
+		}
+		if err := runner.EvaluateExpr(valueAttribute.Expr, &value, nil); err != nil {
+			return tags
+		}
+		values[key] = value
+		tags.keyRanges[key] = block.DefRange
+	}
+
+	for _, block := range dynamicBlocks {
+		if !expandDynamicBlock(runner, block, values, tags.keyRanges) {
+			return tags
+		}
+	}
+	tags.Values = values
+
+	return tags
+}
+
+// Expands a `dynamic` block generating tag blocks into the values, returning false if the tags cannot be known statically.
+// The content can only refer to the iterator, as it is evaluated without the context of the module.
+func expandDynamicBlock(runner tflint.Runner, block *hclext.Block, values map[string]string, keyRanges map[string]hcl.Range) bool {
+	forEachAttribute, exists := block.Body.Attributes["for_each"]
+	contents := block.Body.Blocks.OfType("content")
+	if !exists || len(contents) != 1 {
+		return false
+	}
+	keyAttribute, keyExists := contents[0].Body.Attributes["key"]
+	valueAttribute, valueExists := contents[0].Body.Attributes["value"]
+	if !keyExists || !valueExists {
+		return false
+	}
+
+	iterator := block.Labels[0]
+	if attribute, exists := block.Body.Attributes["iterator"]; exists {
+		traversal, diags := hcl.AbsTraversalForExpr(attribute.Expr)
+		if diags.HasErrors() || len(traversal) != 1 {
+			return false
+		}
+		iterator = traversal.RootName()
+	}
+
+	var forEach cty.Value
+	err := runner.EvaluateExpr(forEachAttribute.Expr, &forEach, nil)
+	if err != nil || !forEach.IsWhollyKnown() || forEach.IsNull() || !forEach.CanIterateElements() {
+		return false
+	}
+
+	for it := forEach.ElementIterator(); it.Next(); {
+		elementKey, element := it.Element()
+		ctx := &hcl.EvalContext{
+			Variables: map[string]cty.Value{
+				iterator: cty.ObjectVal(map[string]cty.Value{"key": elementKey, "value": element}),
+			},
+		}
+
+		key, keyOk := evaluateString(keyAttribute.Expr, ctx)
+		value, valueOk := evaluateString(valueAttribute.Expr, ctx)
+		if !keyOk || !valueOk {
+			return false
+		}
+		values[key] = value
+		keyRanges[key] = block.DefRange
+	}
+
+	return true
+}
+
+// Returns the value of an expression if it evaluates to a known string in the given context
+func evaluateString(expr hcl.Expression, ctx *hcl.EvalContext) (string, bool) {
+	value, diags := expr.Value(ctx)
+	if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() {
+		return "", false
+	}
+	value, err := convert.Convert(value, cty.String)
+	if err != nil {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+// Returns the value of an expression if it is a string that can be evaluated without any context, such as an object key
+func staticString(expr hcl.Expression) (string, bool) {
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || !value.IsKnown() || value.IsNull() || value.Type() != cty.String {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+// Returns the string attribute of an object or map value
+func stringAttribute(value cty.Value, name string) (string, bool) {
+	if value.IsNull() || !value.IsKnown() {
+		return "", false
+	}
+
+	var attribute cty.Value
+	switch {
+	case value.Type().IsObjectType() && value.Type().HasAttribute(name):
+		attribute = value.GetAttr(name)
+	case value.Type().IsMapType() && value.HasIndex(cty.StringVal(name)).True():
+		attribute = value.Index(cty.StringVal(name))
+	default:
+		return "", false
+	}
+
+	if attribute.IsNull() || !attribute.IsKnown() || attribute.Type() != cty.String {
+		return "", false
+	}
+	return attribute.AsString(), true
+}
+
+// Returns the alias of the provider configuration the resource uses ("" for the default provider)
+func providerAlias(resource *hclext.Block) string {
+	attribute, exists := resource.Body.Attributes["provider"]
+	if !exists {
+		return ""
+	}
+
+	_, alias, _ := providerReference(attribute.Expr)
+	return alias
+}
+
+// Returns the name and alias of the provider configuration the expression refers to, e.g. aws and west for aws.west
+func providerReference(expr hcl.Expression) (string, string, bool) {
+	traversal, diags := hcl.AbsTraversalForExpr(expr)
+	if diags.HasErrors() {
+		return "", "", false
+	}
+	if len(traversal) < 2 {
+		return traversal.RootName(), "", true
+	}
+	if step, ok := traversal[1].(hcl.TraverseAttr); ok {
+		return traversal.RootName(), step.Name, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,46 @@
+package tagging
+
+// Google Cloud resource types with a top-level `labels` attribute that the provider `default_labels` are merged into.
+// Unlike tflint-ruleset-aws for AWS there is no generated list to depend on, so this one is maintained by hand.
+var googleLabelResources = []string{
+	"google_alloydb_cluster",
+	"google_alloydb_instance",
+	"google_artifact_registry_repository",
+	"google_bigquery_dataset",
+	"google_bigquery_table",
+	"google_bigtable_instance",
+	"google_cloud_run_v2_job",
+	"google_cloud_run_v2_service",
+	"google_cloudfunctions2_function",
+	"google_cloudfunctions_function",
+	"google_composer_environment",
+	"google_compute_address",
+	"google_compute_disk",
+	"google_compute_external_vpn_gateway",
+	"google_compute_forwarding_rule",
+	"google_compute_global_address",
+	"google_compute_global_forwarding_rule",
+	"google_compute_image",
+	"google_compute_instance",
+	"google_compute_instance_template",
+	"google_compute_region_disk",
+	"google_compute_snapshot",
+	"google_compute_vpn_tunnel",
+	"google_dataflow_job",
+	"google_dataproc_cluster",
+	"google_dataproc_metastore_service",
+	"google_dns_managed_zone",
+	"google_filestore_instance",
+	"google_kms_crypto_key",
+	"google_memcache_instance",
+	"google_notebooks_instance",
+	"google_project",
+	"google_pubsub_subscription",
+	"google_pubsub_topic",
+	"google_redis_instance",
+	"google_secret_manager_secret",
+	"google_spanner_instance",
+	"google_storage_bucket",
+	"google_vertex_ai_dataset",
+	"google_workflows_workflow",
+}
@@ -0,0 +1,45 @@
+package tagging
+
+// Kubernetes resource types with a `metadata` block holding `labels`.
+// Both the versioned and the deprecated unversioned names are listed, as both are still in use.
+var kubernetesLabelResources = []string{
+	"kubernetes_config_map",
+	"kubernetes_config_map_v1",
+	"kubernetes_cron_job",
+	"kubernetes_cron_job_v1",
+	"kubernetes_daemon_set_v1",
+	"kubernetes_daemonset",
+	"kubernetes_deployment",
+	"kubernetes_deployment_v1",
+	"kubernetes_horizontal_pod_autoscaler",
+	"kubernetes_horizontal_pod_autoscaler_v2",
+	"kubernetes_ingress",
+	"kubernetes_ingress_v1",
+	"kubernetes_job",
+	"kubernetes_job_v1",
+	"kubernetes_namespace",
+	"kubernetes_namespace_v1",
+	"kubernetes_network_policy",
+	"kubernetes_network_policy_v1",
+	"kubernetes_persistent_volume",
+	"kubernetes_persistent_volume_claim",
+	"kubernetes_persistent_volume_claim_v1",
+	"kubernetes_persistent_volume_v1",
+	"kubernetes_pod",
+	"kubernetes_pod_disruption_budget_v1",
+	"kubernetes_pod_v1",
+	"kubernetes_role",
+	"kubernetes_role_binding",
+	"kubernetes_role_binding_v1",
+	"kubernetes_role_v1",
+	"kubernetes_secret",
+	"kubernetes_secret_v1",
+	"kubernetes_service",
+	"kubernetes_service_account",
+	"kubernetes_service_account_v1",
+	"kubernetes_service_v1",
+	"kubernetes_stateful_set",
+	"kubernetes_stateful_set_v1",
+	"kubernetes_storage_class",
+	"kubernetes_storage_class_v1",
+}
@@ -0,0 +1,194 @@
+package tagging
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/terraform-linters/tflint-ruleset-aws/rules/tags"
+	"golang.org/x/exp/slices"
+)
+
+// Format describes how tags are written at a location
+type Format int
+
+const (
+	// MapFormat is an attribute holding a map, e.g. `tags = { team = "x" }`
+	MapFormat Format = iota
+	// KeyValueListFormat is an attribute holding a list of objects, e.g. `tags = [{ key = "team", value = "x" }]`
+	KeyValueListFormat
+	// BlockFormat is a repeated block, e.g. `tag { key = "team" value = "x" }`, which can also be generated by a `dynamic` block
+	BlockFormat
+)
+
+// Location is a place in a provider or resource block that holds tags
+type Location struct {
+	// Blocks is the path of nested blocks leading to the tags, e.g. ["default_tags"] for the aws provider
+	Blocks []string
+	// Name is the name of the attribute, or the type of the repeated block for BlockFormat
+	Name string
+	// Format is how the tags are written
+	Format Format
+	// Standalone is set for tags that apply to something other than the resource itself, such as its volumes.
+	// Provider default tags are not merged into them, so they have to carry the required tags on their own.
+	Standalone bool
+	// TypeAttribute is the attribute of the innermost block that names what the tags apply to, e.g. resource_type of tag_specifications
+	TypeAttribute string
+	// RequiredTypes are the values of TypeAttribute that each need a block with tags
+	RequiredTypes []string
+}
+
+// String returns the path to the tags, e.g. root_block_device.tags
+func (l Location) String() string {
+	return strings.Join(append(slices.Clone(l.Blocks), l.Name), ".")
+}
+
+// Provider describes where the resources and provider configurations of a Terraform provider keep their tags
+type Provider interface {
+	// Name returns the provider name, e.g. "aws"
+	Name() string
+
+	// Noun returns what the provider calls tags, e.g. "Tag" or "Label"
+	Noun() string
+
+	// DefaultTags returns the location of the tags applied to every resource in the provider configuration.
+	// It returns nil if the provider has no default tags.
+	DefaultTags() *Location
+
+	// Resources returns the resource types that support tags
+	Resources() []string
+
+	// Supports returns whether the resource type supports tags
+	Supports(resourceType string) bool
+
+	// ResourceTags returns the locations of the tags of the given resource type
+	ResourceTags(resourceType string) []Location
+}
+
+// provider is the Provider implementation for the providers known to the ruleset
+type provider struct {
+	name               string
+	noun               string
+	defaultTags        *Location
+	resources          []string
+	resourceTags       []Location
+	resourceTagsByType map[string][]Location
+
+	supportedOnce sync.Once
+	supported     map[string]bool
+}
+
+// Name returns the provider name
+func (p *provider) Name() string {
+	return p.name
+}
+
+// Noun returns what the provider calls tags
+func (p *provider) Noun() string {
+	return p.noun
+}
+
+// DefaultTags returns the location of the default tags in the provider configuration
+func (p *provider) DefaultTags() *Location {
+	return p.defaultTags
+}
+
+// Resources returns the resource types that support tags
+func (p *provider) Resources() []string {
+	return p.resources
+}
+
+// Supports returns whether the resource type supports tags
+func (p *provider) Supports(resourceType string) bool {
+	p.supportedOnce.Do(func() {
+		p.supported = map[string]bool{}
+		for _, resource := range p.resources {
+			p.supported[resource] = true
+		}
+	})
+	return p.supported[resourceType]
+}
+
+// ResourceTags returns the locations of the tags of the given resource type
+func (p *provider) ResourceTags(resourceType string) []Location {
+	if locations, exists := p.resourceTagsByType[resourceType]; exists {
+		return locations
+	}
+	return p.resourceTags
+}
+
+// AWS keeps tags in the `default_tags` block of the provider and the `tags` attribute of resources
+var AWS Provider = &provider{
+	name:         "aws",
+	noun:         "Tag",
+	defaultTags:  &Location{Blocks: []string{"default_tags"}, Name: "tags", Format: MapFormat},
+	resources:    tags.Resources,
+	resourceTags: []Location{{Name: "tags", Format: MapFormat}},
+	resourceTagsByType: map[string][]Location{
+		// Auto scaling groups take either repeated tag blocks or a list of tag objects, default tags are not applied to either
+		// https://registry.terraform.io/providers/hashicorp/aws/latest/docs/resources/autoscaling_group#tag-and-tags
+		"aws_autoscaling_group": {
+			{Name: "tag", Format: BlockFormat, Standalone: true},
+			{Name: "tags", Format: KeyValueListFormat, Standalone: true},
+		},
+		// Compute environments tag the instances they launch separately
+		"aws_batch_compute_environment": {
+			{Name: "tags", Format: MapFormat},
+			{Blocks: []string{"compute_resources"}, Name: "tags", Format: MapFormat, Standalone: true},
+		},
+		// Instances tag their volumes separately
+		"aws_instance": {
+			{Name: "tags", Format: MapFormat},
+			{Name: "volume_tags", Format: MapFormat, Standalone: true},
+			{Blocks: []string{"root_block_device"}, Name: "tags", Format: MapFormat, Standalone: true},
+			{Blocks: []string{"ebs_block_device"}, Name: "tags", Format: MapFormat, Standalone: true},
+		},
+		// Launch templates tag the instances and volumes launched from them per resource type
+		"aws_launch_template": {
+			{Name: "tags", Format: MapFormat},
+			{
+				Blocks:        []string{"tag_specifications"},
+				Name:          "tags",
+				Format:        MapFormat,
+				Standalone:    true,
+				TypeAttribute: "resource_type",
+				RequiredTypes: []string{"instance", "volume"},
+			},
+		},
+	},
+}
+
+// AWSCC keeps tags in the `tags` attribute of resources as a list of key/value objects and has no default tags
+var AWSCC Provider = &provider{
+	name:         "awscc",
+	noun:         "Tag",
+	resources:    awsccTagResources,
+	resourceTags: []Location{{Name: "tags", Format: KeyValueListFormat}},
+}
+
+// Google keeps labels in the `default_labels` attribute of the provider and the `labels` attribute of resources
+var Google Provider = &provider{
+	name:         "google",
+	noun:         "Label",
+	defaultTags:  &Location{Name: "default_labels", Format: MapFormat},
+	resources:    googleLabelResources,
+	resourceTags: []Location{{Name: "labels", Format: MapFormat}},
+}
+
+// AzureRM keeps tags in the `tags` attribute of resources and has no default tags
+var AzureRM Provider = &provider{
+	name:         "azurerm",
+	noun:         "Tag",
+	resources:    azurermTagResources,
+	resourceTags: []Location{{Name: "tags", Format: MapFormat}},
+}
+
+// Kubernetes keeps labels in the `metadata` block of resources and has no default labels
+var Kubernetes Provider = &provider{
+	name:         "kubernetes",
+	noun:         "Label",
+	resources:    kubernetesLabelResources,
+	resourceTags: []Location{{Blocks: []string{"metadata"}, Name: "labels", Format: MapFormat}},
+}
+
+// Providers is the list of providers the tag rules check
+var Providers = []Provider{AWS, AWSCC, Google, AzureRM, Kubernetes}
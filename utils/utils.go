@@ -4,8 +4,68 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/terraform-linters/tflint-plugin-sdk/helper"
 	"github.com/terraform-linters/tflint-plugin-sdk/tflint"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 func NewIssue(rule tflint.Rule, message string, issueRange hcl.Range) helper.Issue {
 	return helper.Issue{Rule: rule, Message: message, Range: issueRange}
 }
+
+// SortedKeys returns the keys of the map in sorted order, so issues are emitted deterministically
+func SortedKeys[V any](m map[string]V) []string {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	return keys
+}
+
+// ruleWithSeverity overrides the severity of a rule, so a single rule can emit issues of different severities
+type ruleWithSeverity struct {
+	tflint.Rule
+	severity tflint.Severity
+}
+
+// WithSeverity returns the rule with its severity replaced by the given one
+func WithSeverity(rule tflint.Rule, severity tflint.Severity) tflint.Rule {
+	return &ruleWithSeverity{Rule: rule, severity: severity}
+}
+
+// Severity returns the overridden severity
+func (r *ruleWithSeverity) Severity() tflint.Severity {
+	return r.severity
+}
+
+// Violation describes what an issue is about, independent of its message and range, so the same violation can be recognized across runs
+type Violation struct {
+	// Kind of violation, e.g. missing_tags
+	Kind string
+	// Tags the violation is about, if any
+	Tags []string
+}
+
+// Violations is embedded in rules to attach the violation to an issue. Rules emit the issue with a copy of themselves carrying it,
+// so the type of the rule the issue is emitted with stays the same.
+type Violations struct {
+	violation *Violation
+}
+
+// NewViolations returns the violation to attach to a copy of a rule
+func NewViolations(kind string, tags ...string) Violations {
+	return Violations{violation: &Violation{Kind: kind, Tags: tags}}
+}
+
+// Violation returns the attached violation, or nil if there is none
+func (v Violations) Violation() *Violation {
+	return v.violation
+}
+
+// ViolationOf returns the violation attached to the rule, or nil if there is none
+func ViolationOf(rule tflint.Rule) *Violation {
+	if wrapped, ok := rule.(*ruleWithSeverity); ok {
+		rule = wrapped.Rule
+	}
+	if holder, ok := rule.(interface{ Violation() *Violation }); ok {
+		return holder.Violation()
+	}
+	return nil
+}